@@ -0,0 +1,49 @@
+package rununtil
+
+import (
+	"os"
+	"os/signal"
+)
+
+// AwaitKillSignalsWithActions runs the provided RunnerFuncs until the kill
+// signals have been recieved, at which point it executes the graceful shutdown
+// functions in the reverse order to which the runners were registered. Every
+// time one of the signals in actions is received its callback is invoked
+// instead, and it carries on waiting, e.g. to dump the go routine stacks on a
+// SIGUSR1 or toggle debug logging on a SIGUSR2:
+//
+//	rununtil.AwaitKillSignalsWithActions(
+//		[]os.Signal{syscall.SIGINT, syscall.SIGTERM},
+//		map[os.Signal]func(){syscall.SIGUSR1: dumpStacks, syscall.SIGUSR2: toggleDebug},
+//		NewRunner(logger),
+//	)
+//
+// Each callback is invoked in its own go routine, so a slow callback never
+// delays the handling of a kill signal. This means that callbacks may run
+// concurrently with each other, and with the shutdown, so they must be safe
+// to do so.
+func AwaitKillSignalsWithActions(kill []os.Signal, actions map[os.Signal]func(), runnerFuncs ...RunnerFunc) {
+	signals := append([]os.Signal{}, kill...)
+	for sig := range actions {
+		signals = append(signals, sig)
+	}
+	c, finish, release := defaultGroup.killSignalChannels(signal.Notify, signals)
+	defer release()
+
+	shutdowns := startRunners(runnerFuncs)
+
+	for {
+		select {
+		case sig := <-c:
+			if action, ok := actions[sig]; ok && !containsSignal(kill, sig) {
+				go action()
+				continue
+			}
+		case <-finish:
+		}
+		break
+	}
+	defaultGroup.initiateShutdown()
+
+	shutdownWithinDefaultTimeout(func() { shutdownInReverse(shutdowns) })
+}
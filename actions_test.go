@@ -0,0 +1,49 @@
+package rununtil_test
+
+import (
+	"os"
+	"sync/atomic"
+	"syscall"
+	"testing"
+
+	"github.com/mec07/rununtil"
+)
+
+func TestRununtilAwaitKillSignalsWithActions(t *testing.T) {
+	p, err := os.FindProcess(os.Getpid())
+	if err != nil {
+		t.Fatalf("Unexpected error when finding process: %v", err)
+	}
+	sendSignal := func(sig os.Signal) {
+		if err := p.Signal(sig); err != nil {
+			t.Errorf("unexpected error occurred: %v", err)
+		}
+	}
+
+	var shutdown, actedBeforeShutdown int32
+	action := func() {
+		if atomic.LoadInt32(&shutdown) == 0 {
+			atomic.StoreInt32(&actedBeforeShutdown, 1)
+		}
+		sendSignal(syscall.SIGTERM)
+	}
+	runner := rununtil.RunnerFunc(func() rununtil.ShutdownFunc {
+		sendSignal(syscall.SIGHUP)
+		return rununtil.ShutdownFunc(func() {
+			atomic.StoreInt32(&shutdown, 1)
+		})
+	})
+
+	rununtil.AwaitKillSignalsWithActions(
+		[]os.Signal{syscall.SIGTERM},
+		map[os.Signal]func(){syscall.SIGHUP: action},
+		runner,
+	)
+
+	if atomic.LoadInt32(&actedBeforeShutdown) != 1 {
+		t.Fatal("expected the action to have been called without shutting down")
+	}
+	if atomic.LoadInt32(&shutdown) != 1 {
+		t.Fatal("expected the shutdown function to have been called")
+	}
+}
@@ -0,0 +1,143 @@
+package rununtil
+
+import (
+	"github.com/pkg/errors"
+)
+
+// Deps holds the values created by the providers that a provider or runner
+// consumes, keyed by the names of the providers.
+type Deps map[string]interface{}
+
+// ProviderFunc is a nonblocking function that sets up a dependency, e.g. a
+// database connection, from the values of the providers it consumes. It
+// returns the value for the runners which consume it, and a function which
+// can shutdown whatever it set up.
+type ProviderFunc func(deps Deps) (value interface{}, shutdown ShutdownFunc)
+
+// ConsumerFunc is a nonblocking function that sets off the worker go routines,
+// using the values of the providers it consumes, and returns a function which
+// can shutdown those worker go routines.
+type ConsumerFunc func(deps Deps) ShutdownFunc
+
+type provider struct {
+	name     string
+	consumes []string
+	provide  ProviderFunc
+}
+
+type consumer struct {
+	consumes []string
+	run      ConsumerFunc
+}
+
+// Builder composes providers and the runners that consume them into a single
+// RunnerFunc. Use NewBuilder to create a Builder, for example:
+//
+//	runner, err := rununtil.NewBuilder().
+//		Provide("db", nil, NewDB(config)).
+//		Provide("repo", []string{"db"}, NewRepository).
+//		Run([]string{"repo"}, NewWorker).
+//		Build()
+//	if err != nil {
+//		log.Fatal().Err(err).Msg("invalid dependencies")
+//	}
+//	rununtil.AwaitKillSignal(runner)
+type Builder struct {
+	providers []provider
+	consumers []consumer
+}
+
+// NewBuilder creates a new Builder.
+func NewBuilder() *Builder {
+	return &Builder{}
+}
+
+// Provide registers a provider with a name, and the names of the providers
+// that it consumes.
+func (b *Builder) Provide(name string, consumes []string, provide ProviderFunc) *Builder {
+	b.providers = append(b.providers, provider{name: name, consumes: consumes, provide: provide})
+	return b
+}
+
+// Run registers a runner, and the names of the providers that it consumes.
+func (b *Builder) Run(consumes []string, run ConsumerFunc) *Builder {
+	b.consumers = append(b.consumers, consumer{consumes: consumes, run: run})
+	return b
+}
+
+// Build returns a single RunnerFunc which, when it is run, calls the providers
+// after the providers that they consume and then starts the runners in the
+// order that they were registered. Each one is only given the values of the
+// providers that it declared it consumes. Its ShutdownFunc shuts them all down
+// in the reverse order, so that the runners are shutdown before the providers
+// they consume. If the dependencies can't be satisfied, e.g. because they
+// contain a cycle, an error is returned.
+func (b *Builder) Build() (RunnerFunc, error) {
+	if _, err := b.ordered(Deps{}); err != nil {
+		return nil, err
+	}
+	for idx, c := range b.consumers {
+		for _, name := range c.consumes {
+			if !b.provides(name) {
+				return nil, errors.Errorf("runner %d consumes unknown provider %q", idx, name)
+			}
+		}
+	}
+
+	return func() ShutdownFunc {
+		values := make(Deps, len(b.providers))
+		// the dependencies have already been checked
+		runnerFuncs, _ := b.ordered(values)
+		for _, c := range b.consumers {
+			c := c
+			runnerFuncs = append(runnerFuncs, func() ShutdownFunc {
+				return c.run(values.only(c.consumes))
+			})
+		}
+
+		shutdowns := startRunners(runnerFuncs)
+		return func() {
+			shutdownInReverse(shutdowns)
+		}
+	}, nil
+}
+
+// ordered returns RunnerFuncs which call the providers, in an order in which
+// every provider comes after the providers that it consumes. Each one stores
+// its value in values.
+func (b *Builder) ordered(values Deps) ([]RunnerFunc, error) {
+	named := make([]NamedRunner, len(b.providers))
+	for idx, p := range b.providers {
+		p := p
+		named[idx] = NamedRunner{
+			Name:      p.name,
+			DependsOn: p.consumes,
+			Run: func() ShutdownFunc {
+				value, shutdown := p.provide(values.only(p.consumes))
+				values[p.name] = value
+				return shutdown
+			},
+		}
+	}
+
+	ordered, err := sortByDependencies(named)
+	return ordered, errors.Wrap(err, "resolving providers")
+}
+
+func (b *Builder) provides(name string) bool {
+	for _, p := range b.providers {
+		if p.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// only returns the values of the named providers.
+func (d Deps) only(names []string) Deps {
+	deps := make(Deps, len(names))
+	for _, name := range names {
+		deps[name] = d[name]
+	}
+	return deps
+}
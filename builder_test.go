@@ -0,0 +1,82 @@
+package rununtil_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/mec07/rununtil"
+)
+
+func TestBuilder(t *testing.T) {
+	var order []string
+	var workerDeps rununtil.Deps
+	shutdown := func(name string) rununtil.ShutdownFunc {
+		return rununtil.ShutdownFunc(func() {
+			order = append(order, name)
+		})
+	}
+
+	runner, err := rununtil.NewBuilder().
+		Provide("repo", []string{"db"}, func(deps rununtil.Deps) (interface{}, rununtil.ShutdownFunc) {
+			return "repo(" + deps["db"].(string) + ")", shutdown("repo")
+		}).
+		Provide("db", nil, func(deps rununtil.Deps) (interface{}, rununtil.ShutdownFunc) {
+			return "db", shutdown("db")
+		}).
+		Run([]string{"repo"}, func(deps rununtil.Deps) rununtil.ShutdownFunc {
+			workerDeps = deps
+			return shutdown("worker")
+		}).
+		Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	rununtil.AwaitKillSignal(runner, helperMakeCancellingRunner())
+
+	expectedDeps := rununtil.Deps{"repo": "repo(db)"}
+	if !reflect.DeepEqual(workerDeps, expectedDeps) {
+		t.Fatalf("expected the worker to be given %v, got: %v", expectedDeps, workerDeps)
+	}
+	expectedOrder := []string{"worker", "repo", "db"}
+	if !reflect.DeepEqual(order, expectedOrder) {
+		t.Fatalf("expected shutdown order %v, got: %v", expectedOrder, order)
+	}
+}
+
+func TestBuilder_Invalid(t *testing.T) {
+	provide := func(deps rununtil.Deps) (interface{}, rununtil.ShutdownFunc) {
+		return nil, nil
+	}
+	run := func(deps rununtil.Deps) rununtil.ShutdownFunc {
+		return nil
+	}
+	table := []struct {
+		name     string
+		builder  *rununtil.Builder
+		expected string
+	}{
+		{
+			name:     "Cycle",
+			builder:  rununtil.NewBuilder().Provide("a", []string{"b"}, provide).Provide("b", []string{"a"}, provide),
+			expected: "resolving providers: dependency cycle: a -> b -> a",
+		},
+		{
+			name:     "UnknownProvider",
+			builder:  rununtil.NewBuilder().Provide("db", nil, provide).Run([]string{"db", "cache"}, run),
+			expected: `runner 0 consumes unknown provider "cache"`,
+		},
+	}
+
+	for _, test := range table {
+		t.Run(test.name, func(t *testing.T) {
+			runner, err := test.builder.Build()
+			if err == nil || err.Error() != test.expected {
+				t.Fatalf("expected error %q, got: %v", test.expected, err)
+			}
+			if runner != nil {
+				t.Fatal("did not expect a runner to be returned")
+			}
+		})
+	}
+}
@@ -0,0 +1,17 @@
+package rununtil
+
+import "os"
+
+// AwaitOnChannel runs the provided RunnerFuncs until a signal is received on
+// sigCh, or SimulateKillSignal or CancelAll is called, at which point it
+// executes the graceful shutdown functions in the reverse order to which the
+// runners were registered. It does not call signal.Notify itself, so that one
+// central go routine can own the signal handling for the whole process and
+// fan the signals out to several awaits:
+//
+//	sigCh := make(chan os.Signal, 1)
+//	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+//	rununtil.AwaitOnChannel(sigCh, NewRunner(logger))
+func AwaitOnChannel(sigCh <-chan os.Signal, runnerFuncs ...RunnerFunc) {
+	Await(runnerFuncs, WithSignalChannel(sigCh))
+}
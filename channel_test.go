@@ -0,0 +1,44 @@
+package rununtil_test
+
+import (
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/mec07/rununtil"
+)
+
+func TestRununtilAwaitOnChannel(t *testing.T) {
+	var stopped []chan<- os.Signal
+	restore := rununtil.SetSignalStop(func(c chan<- os.Signal) {
+		stopped = append(stopped, c)
+	})
+	defer restore()
+
+	sigCh := make(chan os.Signal, 1)
+	var hasBeenShutdown bool
+	sendingRunner := rununtil.RunnerFunc(func() rununtil.ShutdownFunc {
+		sigCh <- syscall.SIGTERM
+		return nil
+	})
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		rununtil.AwaitOnChannel(sigCh, helperMakeFakeRunner(&hasBeenShutdown), sendingRunner)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		rununtil.CancelAll()
+		t.Fatal("expected the signal on the channel to have stopped the await")
+	}
+	if !hasBeenShutdown {
+		t.Fatal("expected the shutdown function to have been called")
+	}
+	if len(stopped) != 0 {
+		t.Fatal("did not expect the await to have registered its own channel with signal.Notify")
+	}
+}
@@ -0,0 +1,36 @@
+package rununtil
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/pkg/errors"
+)
+
+// Closer returns a RunnerFunc which does nothing when it is started, and whose
+// ShutdownFunc closes c, so that the clean up of, e.g., a database pool can be
+// registered in one line:
+//
+//	rununtil.AwaitKillSignal(NewHTTPRunner(db), rununtil.Closer(db))
+//
+// An error returned by Close is reported on stderr. To have it returned by
+// the await instead, use CloserE with AwaitKillSignalE.
+func Closer(c io.Closer) RunnerFunc {
+	return func() ShutdownFunc {
+		return func() {
+			if err := c.Close(); err != nil {
+				fmt.Fprintf(os.Stderr, "ERROR: %+v\n", errors.Wrap(err, "closing"))
+			}
+		}
+	}
+}
+
+// CloserE is the same as Closer except that it returns a RunnerFuncE, so that
+// an error returned by Close is included in the *ShutdownError returned by
+// AwaitKillSignalE.
+func CloserE(c io.Closer) RunnerFuncE {
+	return func() ShutdownFuncE {
+		return c.Close
+	}
+}
@@ -0,0 +1,45 @@
+package rununtil_test
+
+import (
+	stderrors "errors"
+	"os"
+	"syscall"
+	"testing"
+
+	"github.com/mec07/rununtil"
+	"github.com/pkg/errors"
+)
+
+type fakeCloser struct {
+	closed bool
+	err    error
+}
+
+func (c *fakeCloser) Close() error {
+	c.closed = true
+	return c.err
+}
+
+func TestRununtilCloser(t *testing.T) {
+	closer := &fakeCloser{err: errors.New("already closed")}
+
+	rununtil.AwaitKillSignals([]os.Signal{syscall.SIGINT}, rununtil.Closer(closer), helperMakeCancellingRunner())
+
+	if !closer.closed {
+		t.Fatal("expected the closer to have been closed")
+	}
+}
+
+func TestRununtilCloserE(t *testing.T) {
+	errClose := errors.New("flush failed")
+	closer := &fakeCloser{err: errClose}
+
+	err := rununtil.AwaitKillSignalsE([]os.Signal{syscall.SIGINT}, rununtil.CloserE(closer), helperMakeCancellingRunnerE())
+
+	if !closer.closed {
+		t.Fatal("expected the closer to have been closed")
+	}
+	if !stderrors.Is(err, errClose) {
+		t.Fatalf("expected the close error to be returned, got: %v", err)
+	}
+}
@@ -0,0 +1,24 @@
+package rununtil
+
+// Combine bundles the runners into a single RunnerFunc, so that related
+// runners, e.g. a metrics server and a pprof server, can be passed around and
+// registered as one logical unit:
+//
+//	debug := rununtil.Combine(NewMetricsRunner(config), NewPprofRunner(config))
+//	rununtil.AwaitKillSignal(NewDBRunner(config), debug, NewHTTPRunner(config))
+//
+// The runners are started in order, and their shutdown functions are executed
+// in the reverse order, in the same way as if they had been passed to the
+// await directly. Combined runners can themselves be combined. If a runner
+// panics while starting up, the runners of the bundle which have already
+// started are shutdown before the panic is propagated. A panic in one shutdown
+// function does not prevent the others in the bundle from being executed; once
+// they have all returned the first panic is propagated.
+func Combine(runnerFuncs ...RunnerFunc) RunnerFunc {
+	return func() ShutdownFunc {
+		shutdowns := startRunners(runnerFuncs)
+		return func() {
+			shutdownInReverse(shutdowns)
+		}
+	}
+}
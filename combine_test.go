@@ -0,0 +1,44 @@
+package rununtil_test
+
+import (
+	"os"
+	"reflect"
+	"syscall"
+	"testing"
+
+	"github.com/mec07/rununtil"
+)
+
+func TestRununtilCombine(t *testing.T) {
+	runner, order := rununtil.RecordShutdownOrder()
+	bundle := rununtil.Combine(runner(2), rununtil.Combine(runner(3), runner(4)))
+
+	rununtil.AwaitKillSignals([]os.Signal{syscall.SIGINT}, runner(1), bundle, runner(5), helperMakeCancellingRunner())
+
+	expected := []int{5, 4, 3, 2, 1}
+	if !reflect.DeepEqual(*order, expected) {
+		t.Fatalf("expected shutdown order %v, got: %v", expected, *order)
+	}
+}
+
+func TestRununtilCombine_Panic(t *testing.T) {
+	runner, order := rununtil.RecordShutdownOrder()
+	panickingRunner := rununtil.RunnerFunc(func() rununtil.ShutdownFunc {
+		return func() { panic("failed to shutdown") }
+	})
+	shutdown := rununtil.Combine(runner(1), panickingRunner, runner(2))()
+
+	func() {
+		defer func() {
+			if recovered := recover(); recovered != "failed to shutdown" {
+				t.Fatalf("expected the panic to be propagated, got: %v", recovered)
+			}
+		}()
+		shutdown()
+	}()
+
+	expected := []int{2, 1}
+	if !reflect.DeepEqual(*order, expected) {
+		t.Fatalf("expected all of the other shutdown functions to have been called, got: %v", *order)
+	}
+}
@@ -0,0 +1,163 @@
+package rununtil
+
+import (
+	"context"
+	"os"
+	"time"
+)
+
+// RunnerFuncCtx is a nonblocking function that sets off the worker go routines
+// and returns a function which can shutdown those worker go routines. The
+// context it is given is cancelled as soon as a shutdown has been initiated,
+// so the worker go routines can select on ctx.Done() to start winding down.
+type RunnerFuncCtx func(ctx context.Context) ShutdownFunc
+
+// groupKey is the key under which the Group is stored in a context.
+type groupKey struct{}
+
+// ContextWithGroup returns a copy of ctx which carries the Group, so that code
+// which is handed the context can retrieve it with FromContext. The contexts
+// created by NewGroupContext, and those passed to the hooks and pre shutdown
+// function of an await, already carry their Group.
+func ContextWithGroup(ctx context.Context, g *Group) context.Context {
+	return context.WithValue(ctx, groupKey{}, g)
+}
+
+// FromContext returns the Group carried by ctx, or the default Group if it
+// does not carry one, so that any layer of the code can initiate a graceful
+// shutdown without reaching for a global:
+//
+//	if err := db.Ping(ctx); err != nil {
+//		rununtil.FromContext(ctx).Shutdown()
+//	}
+func FromContext(ctx context.Context) *Group {
+	if g, ok := ctx.Value(groupKey{}).(*Group); ok && g != nil {
+		return g
+	}
+	return defaultGroup
+}
+
+// AwaitKillSignalCtx runs the provided RunnerFuncCtxs until it receives a kill
+// signal, SIGINT or SIGTERM, at which point it cancels the context passed to
+// the runners and then executes the graceful shutdown functions.
+func AwaitKillSignalCtx(runnerFuncs ...RunnerFuncCtx) {
+	AwaitKillSignalsCtx(defaultSignals(), runnerFuncs...)
+}
+
+// AwaitKillSignalsCtx runs the provided RunnerFuncCtxs until the specified
+// signals have been recieved, at which point it cancels the context passed to
+// the runners and then executes the graceful shutdown functions.
+func AwaitKillSignalsCtx(signals []os.Signal, runnerFuncs ...RunnerFuncCtx) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	wait, release := defaultGroup.listenForKillSignal(signals)
+	defer release()
+
+	runners := make([]RunnerFunc, 0, len(runnerFuncs))
+	for _, runner := range runnerFuncs {
+		runner := runner
+		runners = append(runners, RunnerFunc(func() ShutdownFunc {
+			return runner(ctx)
+		}))
+	}
+	shutdowns := startRunners(runners)
+
+	wait()
+	// cancel the context before the shutdown functions are run
+	cancel()
+	shutdownWithinDefaultTimeout(func() { shutdownInReverse(shutdowns) })
+}
+
+// AwaitContext runs the provided RunnerFuncs until the context is cancelled,
+// or SimulateKillSignal or CancelAll is called, at which point it executes the
+// graceful shutdown functions in the reverse order to which the runners were
+// registered. It does not listen for any signals itself, so the caller is free
+// to use signal.NotifyContext or any other source of cancellation, e.g. a root
+// context managed by a framework:
+//
+//	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+//	defer stop()
+//	rununtil.AwaitContext(ctx, NewRunner(logger))
+func AwaitContext(ctx context.Context, runnerFuncs ...RunnerFunc) {
+	RunUntilCancel(ctx.Done(), runnerFuncs...)
+}
+
+// RunUntilCancel runs the provided RunnerFuncs until the cancel channel is
+// closed, or SimulateKillSignal or CancelAll is called, at which point it
+// executes the graceful shutdown functions in the reverse order to which the
+// runners were registered. It does everything that AwaitKillSignals does
+// except for calling signal.Notify, so it can be embedded in a larger process
+// which already owns the signal handling, e.g. another framework's lifecycle.
+func RunUntilCancel(cancel <-chan struct{}, runnerFuncs ...RunnerFunc) {
+	finish, release := defaultGroup.finishChannel()
+	defer release()
+
+	shutdowns := startRunners(runnerFuncs)
+
+	select {
+	case <-cancel:
+	case <-finish:
+	}
+	defaultGroup.initiateShutdown()
+
+	shutdownWithinDefaultTimeout(func() { shutdownInReverse(shutdowns) })
+}
+
+// AwaitKillSignalsWithContext runs the provided RunnerFuncs until the
+// specified signals have been recieved, SimulateKillSignal or CancelAll is
+// called, or the provided context is cancelled, at which point it executes the
+// graceful shutdown functions in the reverse order to which the runners were
+// registered. This allows an outer orchestrator, e.g. a supervising go routine
+// with its own deadline, to trigger a graceful shutdown through the same path
+// as a kill signal.
+func AwaitKillSignalsWithContext(ctx context.Context, signals []os.Signal, runnerFuncs ...RunnerFunc) {
+	Await(runnerFuncs, WithSignals(signals...), WithContext(ctx))
+}
+
+// ShutdownFuncCtx is a function that should be returned by a
+// RunnerFuncShutdownCtx which gracefully shuts down whatever is being run. The
+// context it is given carries the deadline for the shutdown, so it can be
+// passed straight to, e.g., http.Server.Shutdown.
+type ShutdownFuncCtx func(ctx context.Context)
+
+// RunnerFuncShutdownCtx is a nonblocking function that sets off the worker go
+// routines and returns a function which can shutdown those worker go routines
+// within the deadline of the context it is given.
+type RunnerFuncShutdownCtx func() ShutdownFuncCtx
+
+// AwaitKillSignalsWithShutdownContext runs the provided RunnerFuncShutdownCtxs
+// until the specified signals have been recieved, at which point it executes
+// the graceful shutdown functions in the reverse order to which the runners
+// were registered. All of the shutdown functions are given the same context,
+// whose deadline is timeout after the shutdown started, so it is up to the
+// shutdown functions to respect it. If a runner panics while starting up, the
+// runners which have already started are given a context without a deadline.
+func AwaitKillSignalsWithShutdownContext(signals []os.Signal, timeout time.Duration, runnerFuncs ...RunnerFuncShutdownCtx) {
+	wait, release := defaultGroup.listenForKillSignal(signals)
+	defer release()
+
+	// the shutdown functions read ctx when they are executed, so that it only
+	// gets its deadline once the signal has been received
+	ctx := context.Background()
+	runners := make([]RunnerFunc, 0, len(runnerFuncs))
+	for _, runner := range runnerFuncs {
+		runner := runner
+		runners = append(runners, RunnerFunc(func() ShutdownFunc {
+			shutdown := runner()
+			if shutdown == nil {
+				return nil
+			}
+			return func() {
+				shutdown(ctx)
+			}
+		}))
+	}
+	shutdowns := startRunners(runners)
+
+	wait()
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	shutdownInReverse(shutdowns)
+}
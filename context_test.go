@@ -0,0 +1,240 @@
+package rununtil_test
+
+import (
+	"context"
+	"os"
+	"reflect"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/mec07/rununtil"
+)
+
+func helperMakeFakeRunnerCtx(ctxCancelledBeforeShutdown, hasBeenShutdown *bool) rununtil.RunnerFuncCtx {
+	return rununtil.RunnerFuncCtx(func(ctx context.Context) rununtil.ShutdownFunc {
+		return rununtil.ShutdownFunc(func() {
+			*ctxCancelledBeforeShutdown = ctx.Err() != nil
+			*hasBeenShutdown = true
+		})
+	})
+}
+
+func TestRununtilAwaitKillSignalCtx(t *testing.T) {
+	var ctxCancelled, hasBeenShutdown bool
+	p, err := os.FindProcess(os.Getpid())
+	if err != nil {
+		t.Fatalf("Unexpected error when finding process: %v", err)
+	}
+
+	go helperSignalOnceListening(t, p, syscall.SIGINT)
+	rununtil.AwaitKillSignalCtx(helperMakeFakeRunnerCtx(&ctxCancelled, &hasBeenShutdown))
+
+	if !hasBeenShutdown {
+		t.Fatal("expected the shutdown function to have been called")
+	}
+	if !ctxCancelled {
+		t.Fatal("expected the context to have been cancelled before the shutdown function was called")
+	}
+}
+
+func TestRununtilAwaitKillSignalsCtx_CancelAll(t *testing.T) {
+	var ctxCancelled, hasBeenShutdown bool
+	workerStopped := make(chan struct{})
+
+	worker := rununtil.RunnerFuncCtx(func(ctx context.Context) rununtil.ShutdownFunc {
+		go func() {
+			<-ctx.Done()
+			close(workerStopped)
+		}()
+		rununtil.CancelAll()
+		return rununtil.ShutdownFunc(func() {})
+	})
+
+	rununtil.AwaitKillSignalsCtx(
+		[]os.Signal{syscall.SIGINT},
+		helperMakeFakeRunnerCtx(&ctxCancelled, &hasBeenShutdown),
+		worker,
+	)
+
+	select {
+	case <-workerStopped:
+	case <-time.After(time.Second):
+		t.Fatal("expected the worker to have seen the context being cancelled")
+	}
+	if !hasBeenShutdown {
+		t.Fatal("expected the shutdown function to have been called")
+	}
+	if !ctxCancelled {
+		t.Fatal("expected the context to have been cancelled before the shutdown function was called")
+	}
+}
+
+func TestRununtilAwaitKillSignalsWithShutdownContext(t *testing.T) {
+	var deadlines []time.Time
+	runner := rununtil.RunnerFuncShutdownCtx(func() rununtil.ShutdownFuncCtx {
+		return rununtil.ShutdownFuncCtx(func(ctx context.Context) {
+			deadline, ok := ctx.Deadline()
+			if !ok {
+				t.Error("expected the shutdown context to have a deadline")
+			}
+			deadlines = append(deadlines, deadline)
+		})
+	})
+	cancellingRunner := rununtil.RunnerFuncShutdownCtx(func() rununtil.ShutdownFuncCtx {
+		rununtil.CancelAll()
+		return rununtil.ShutdownFuncCtx(func(ctx context.Context) {})
+	})
+
+	start := time.Now()
+	rununtil.AwaitKillSignalsWithShutdownContext(
+		[]os.Signal{syscall.SIGINT},
+		time.Minute,
+		runner,
+		runner,
+		cancellingRunner,
+	)
+
+	if len(deadlines) != 2 {
+		t.Fatalf("expected both shutdown functions to have been called, got: %d", len(deadlines))
+	}
+	if !deadlines[0].Equal(deadlines[1]) {
+		t.Fatal("expected the shutdown functions to share the same deadline")
+	}
+	if deadlines[0].Before(start.Add(time.Minute)) || deadlines[0].After(time.Now().Add(time.Minute)) {
+		t.Fatalf("expected the deadline to be a minute after the shutdown started, got: %v", deadlines[0])
+	}
+}
+
+func TestRununtilAwaitKillSignalsWithShutdownContext_Expires(t *testing.T) {
+	var ctxErr error
+	runner := rununtil.RunnerFuncShutdownCtx(func() rununtil.ShutdownFuncCtx {
+		rununtil.CancelAll()
+		return rununtil.ShutdownFuncCtx(func(ctx context.Context) {
+			<-ctx.Done()
+			ctxErr = ctx.Err()
+		})
+	})
+
+	rununtil.AwaitKillSignalsWithShutdownContext([]os.Signal{syscall.SIGINT}, 5*time.Millisecond, runner)
+
+	if ctxErr != context.DeadlineExceeded {
+		t.Fatalf("expected the context deadline to have been exceeded, got: %v", ctxErr)
+	}
+}
+
+func TestRununtilAwaitContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	var hasBeenShutdown bool
+	cancellingRunner := rununtil.RunnerFunc(func() rununtil.ShutdownFunc {
+		cancel()
+		return nil
+	})
+
+	rununtil.AwaitContext(ctx, helperMakeFakeRunner(&hasBeenShutdown), cancellingRunner)
+
+	if !hasBeenShutdown {
+		t.Fatal("expected the shutdown function to have been called")
+	}
+}
+
+func TestRununtilAwaitContext_CancelAll(t *testing.T) {
+	var hasBeenShutdown bool
+	rununtil.AwaitContext(context.Background(), helperMakeFakeRunner(&hasBeenShutdown), helperMakeCancellingRunner())
+
+	if !hasBeenShutdown {
+		t.Fatal("expected the shutdown function to have been called")
+	}
+}
+
+func TestRununtilAwaitKillSignalsWithContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	var hasBeenShutdown bool
+	cancellingRunner := rununtil.RunnerFunc(func() rununtil.ShutdownFunc {
+		cancel()
+		return nil
+	})
+
+	rununtil.AwaitKillSignalsWithContext(ctx, []os.Signal{syscall.SIGINT}, helperMakeFakeRunner(&hasBeenShutdown), cancellingRunner)
+
+	if !hasBeenShutdown {
+		t.Fatal("expected the shutdown function to have been called")
+	}
+}
+
+func TestRununtilAwaitKillSignalsWithContext_Signal(t *testing.T) {
+	var hasBeenShutdown bool
+	p, err := os.FindProcess(os.Getpid())
+	if err != nil {
+		t.Fatalf("Unexpected error when finding process: %v", err)
+	}
+
+	go helperSignalOnceListening(t, p, syscall.SIGINT)
+	rununtil.AwaitKillSignalsWithContext(context.Background(), []os.Signal{syscall.SIGINT}, helperMakeFakeRunner(&hasBeenShutdown))
+
+	if !hasBeenShutdown {
+		t.Fatal("expected the shutdown function to have been called")
+	}
+}
+
+func TestRununtilRunUntilCancel(t *testing.T) {
+	cancel := make(chan struct{})
+	var order []int
+	closingRunner := rununtil.RunnerFunc(func() rununtil.ShutdownFunc {
+		close(cancel)
+		return nil
+	})
+
+	rununtil.RunUntilCancel(cancel, helperMakeOrderedRunner(1, &order), helperMakeOrderedRunner(2, &order), closingRunner)
+
+	expected := []int{2, 1}
+	if !reflect.DeepEqual(order, expected) {
+		t.Fatalf("expected shutdown order %v, got: %v", expected, order)
+	}
+}
+
+func TestRununtilFromContext(t *testing.T) {
+	group, ctx := rununtil.NewGroupContext(context.Background())
+	if rununtil.FromContext(ctx) != group {
+		t.Fatal("expected the context of the group to carry the group")
+	}
+	if rununtil.FromContext(context.Background()) != rununtil.DefaultGroup() {
+		t.Fatal("expected a context without a group to fall back to the default group")
+	}
+
+	var hasBeenShutdown bool
+	shutdownRunner := rununtil.RunnerFunc(func() rununtil.ShutdownFunc {
+		go rununtil.FromContext(ctx).Shutdown()
+		return nil
+	})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		group.AwaitKillSignal(helperMakeFakeRunner(&hasBeenShutdown), shutdownRunner)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		group.Stop()
+		t.Fatal("expected Shutdown on the group from the context to have stopped the await")
+	}
+	if !hasBeenShutdown {
+		t.Fatal("expected the shutdown function to have been called")
+	}
+}
+
+func TestRununtilFromContext_Hooks(t *testing.T) {
+	group := rununtil.NewGroup()
+	var fromHook *rununtil.Group
+	group.Await(
+		[]rununtil.RunnerFunc{helperMakeCancellingRunner()},
+		rununtil.WithHooks(rununtil.Hooks{OnShutdownStart: func(ctx context.Context) {
+			fromHook = rununtil.FromContext(ctx)
+		}}),
+	)
+
+	if fromHook != group {
+		t.Fatal("expected the shutdown context to carry the group")
+	}
+}
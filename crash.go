@@ -0,0 +1,49 @@
+package rununtil
+
+import (
+	"fmt"
+	"os"
+	"runtime/debug"
+)
+
+// Go runs fn in a new go routine. If fn panics, the panic is recovered and
+// reported on stderr, and the default Group is stopped, in the same way as
+// Stop, so that the shutdown functions get to run instead of the process
+// crashing, even if the Group was created with WithSimulateDisabled. Once the
+// shutdown has completed the await exits the process with status code 1. Use
+// it instead of a bare go statement in your RunnerFuncs:
+//
+//	rununtil.Go(func() { runHTTPServer(srv) })
+//
+// Only the awaits built on Await, e.g. AwaitKillSignal and AwaitKillSignals,
+// exit the process after a crash.
+func Go(fn func()) {
+	go func() {
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				fmt.Fprintf(os.Stderr, "ERROR: recovered from panic in go routine, initiating shutdown: %v\n%s", recovered, debug.Stack())
+				defaultGroup.recordCrash()
+				defaultGroup.Stop()
+			}
+		}()
+		fn()
+	}()
+}
+
+// recordCrash marks the Group as having had a go routine crash, so that the
+// await exits with a non-zero status code once it has shutdown.
+func (g *Group) recordCrash() {
+	g.crashMux.Lock()
+	defer g.crashMux.Unlock()
+	g.crashed = true
+}
+
+// takeCrash reports whether a go routine has crashed since it was last
+// called.
+func (g *Group) takeCrash() bool {
+	g.crashMux.Lock()
+	defer g.crashMux.Unlock()
+	crashed := g.crashed
+	g.crashed = false
+	return crashed
+}
@@ -0,0 +1,80 @@
+package rununtil_test
+
+import (
+	"os"
+	"syscall"
+	"testing"
+
+	"github.com/mec07/rununtil"
+)
+
+func TestRununtilGo(t *testing.T) {
+	exitCode := -1
+	restore := rununtil.SetOsExit(func(code int) { exitCode = code })
+	defer restore()
+
+	var hasBeenShutdown bool
+	crashingRunner := rununtil.RunnerFunc(func() rununtil.ShutdownFunc {
+		rununtil.Go(func() {
+			panic("crashed")
+		})
+		return nil
+	})
+
+	rununtil.AwaitKillSignals([]os.Signal{syscall.SIGINT}, helperMakeFakeRunner(&hasBeenShutdown), crashingRunner)
+
+	if !hasBeenShutdown {
+		t.Fatal("expected the shutdown function to have been called")
+	}
+	if exitCode != 1 {
+		t.Fatalf("expected the process to exit with code 1, got: %d", exitCode)
+	}
+}
+
+func TestRununtilGo_NoPanic(t *testing.T) {
+	exitCode := -1
+	restore := rununtil.SetOsExit(func(code int) { exitCode = code })
+	defer restore()
+
+	ran := make(chan struct{})
+	runner := rununtil.RunnerFunc(func() rununtil.ShutdownFunc {
+		rununtil.Go(func() {
+			close(ran)
+		})
+		<-ran
+		rununtil.CancelAll()
+		return nil
+	})
+
+	rununtil.AwaitKillSignals([]os.Signal{syscall.SIGINT}, runner)
+
+	if exitCode != -1 {
+		t.Fatalf("did not expect the process to be forced to exit, got code: %d", exitCode)
+	}
+}
+
+func TestRununtilGo_SimulateDisabled(t *testing.T) {
+	original := rununtil.DefaultGroup()
+	defer rununtil.SetDefaultGroup(original)
+	rununtil.SetDefaultGroup(rununtil.NewGroup(rununtil.WithSimulateDisabled()))
+	exitCode := -1
+	restore := rununtil.SetOsExit(func(code int) { exitCode = code })
+	defer restore()
+
+	var hasBeenShutdown bool
+	crashingRunner := rununtil.RunnerFunc(func() rununtil.ShutdownFunc {
+		rununtil.Go(func() {
+			panic("crashed")
+		})
+		return nil
+	})
+
+	rununtil.AwaitKillSignals([]os.Signal{syscall.SIGINT}, helperMakeFakeRunner(&hasBeenShutdown), crashingRunner)
+
+	if !hasBeenShutdown {
+		t.Fatal("expected the crash to have shutdown the group despite simulating being disabled")
+	}
+	if exitCode != 1 {
+		t.Fatalf("expected the process to exit with code 1, got: %d", exitCode)
+	}
+}
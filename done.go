@@ -0,0 +1,69 @@
+package rununtil
+
+import (
+	"os"
+	"os/signal"
+	"sync"
+)
+
+// RunnerFuncDone is a nonblocking function that sets off the worker go
+// routines and returns a function which can shutdown those worker go routines,
+// along with a channel which is closed once the worker go routines have
+// finished their work. It is intended for finite jobs, e.g. a batch import,
+// rather than servers.
+type RunnerFuncDone func() (ShutdownFunc, <-chan struct{})
+
+// AwaitKillSignalOrDone runs the provided RunnerFuncDones until either it
+// receives a kill signal, SIGINT or SIGTERM, or every runner has finished its
+// work, at which point it executes the graceful shutdown functions.
+func AwaitKillSignalOrDone(runnerFuncs ...RunnerFuncDone) {
+	AwaitKillSignalsOrDone(defaultSignals(), runnerFuncs...)
+}
+
+// AwaitKillSignalsOrDone runs the provided RunnerFuncDones until either the
+// specified signals have been recieved or every runner has closed its done
+// channel, at which point it executes the graceful shutdown functions in the
+// reverse order to which the runners were registered. If there are no runners
+// it returns straight away.
+func AwaitKillSignalsOrDone(signals []os.Signal, runnerFuncs ...RunnerFuncDone) {
+	c, finish, release := defaultGroup.killSignalChannels(signal.Notify, signals)
+	defer release()
+
+	stop := make(chan struct{})
+	defer close(stop)
+
+	var wg sync.WaitGroup
+	runners := make([]RunnerFunc, 0, len(runnerFuncs))
+	for _, runner := range runnerFuncs {
+		runner := runner
+		runners = append(runners, RunnerFunc(func() ShutdownFunc {
+			shutdown, done := runner()
+
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				select {
+				case <-done:
+				case <-stop:
+				}
+			}()
+			return shutdown
+		}))
+	}
+	shutdowns := startRunners(runners)
+
+	allDone := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(allDone)
+	}()
+
+	select {
+	case <-c:
+	case <-finish:
+	case <-allDone:
+	}
+	defaultGroup.initiateShutdown()
+
+	shutdownWithinDefaultTimeout(func() { shutdownInReverse(shutdowns) })
+}
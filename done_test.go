@@ -0,0 +1,70 @@
+package rununtil_test
+
+import (
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/mec07/rununtil"
+)
+
+func helperMakeFakeRunnerDone(done <-chan struct{}, hasBeenShutdown *bool) rununtil.RunnerFuncDone {
+	return rununtil.RunnerFuncDone(func() (rununtil.ShutdownFunc, <-chan struct{}) {
+		return rununtil.ShutdownFunc(func() {
+			*hasBeenShutdown = true
+		}), done
+	})
+}
+
+func TestRununtilAwaitKillSignalsOrDone_AllDone(t *testing.T) {
+	var hasBeenShutdown1, hasBeenShutdown2 bool
+	done1 := make(chan struct{})
+	done2 := make(chan struct{})
+
+	finished := make(chan struct{})
+	go func() {
+		rununtil.AwaitKillSignalsOrDone(
+			[]os.Signal{syscall.SIGINT},
+			helperMakeFakeRunnerDone(done1, &hasBeenShutdown1),
+			helperMakeFakeRunnerDone(done2, &hasBeenShutdown2),
+		)
+		close(finished)
+	}()
+
+	close(done1)
+	select {
+	case <-finished:
+		t.Fatal("did not expect the await to return before every runner is done")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	close(done2)
+	select {
+	case <-finished:
+	case <-time.After(time.Second):
+		t.Fatal("expected the await to return once every runner is done")
+	}
+	if !hasBeenShutdown1 || !hasBeenShutdown2 {
+		t.Fatal("expected all the shutdown functions to have been called")
+	}
+}
+
+func TestRununtilAwaitKillSignalsOrDone_Cancelled(t *testing.T) {
+	var hasBeenShutdown bool
+	neverDone := make(chan struct{})
+	cancellingRunner := rununtil.RunnerFuncDone(func() (rununtil.ShutdownFunc, <-chan struct{}) {
+		rununtil.CancelAll()
+		return rununtil.ShutdownFunc(func() {}), neverDone
+	})
+
+	rununtil.AwaitKillSignalsOrDone(
+		[]os.Signal{syscall.SIGINT},
+		helperMakeFakeRunnerDone(neverDone, &hasBeenShutdown),
+		cancellingRunner,
+	)
+
+	if !hasBeenShutdown {
+		t.Fatal("expected the shutdown function to have been called")
+	}
+}
@@ -0,0 +1,98 @@
+package rununtil
+
+import (
+	"os"
+	"time"
+)
+
+// DrainFunc is a function that should be returned by a TwoPhaseRunnerFunc
+// which stops whatever is being run from accepting new work, e.g. by marking a
+// health check as unhealthy, while allowing in-flight work to complete.
+type DrainFunc func()
+
+// TwoPhaseRunnerFunc is a nonblocking function that sets off the worker go
+// routines and returns a function which drains them and a function which can
+// shutdown those worker go routines. Either function may be nil.
+type TwoPhaseRunnerFunc func() (DrainFunc, ShutdownFunc)
+
+// AwaitKillSignalsDrain runs the provided TwoPhaseRunnerFuncs until the
+// specified signals have been recieved, at which point it executes all of the
+// drain functions, waits for drainWindow to give in-flight work a chance to
+// complete, and then executes all of the graceful shutdown functions. Both the
+// drain and the shutdown functions are executed in the reverse order to which
+// the runners were registered. For example, to give a load balancer time to
+// notice that the health check is failing before closing the listeners:
+//
+//	rununtil.AwaitKillSignalsDrain(signals, 10*time.Second, NewRunner(logger))
+func AwaitKillSignalsDrain(signals []os.Signal, drainWindow time.Duration, runnerFuncs ...TwoPhaseRunnerFunc) {
+	AwaitKillSignalsDrainWait(signals, DrainWindow(drainWindow), runnerFuncs...)
+}
+
+// DrainWait blocks between the drain and the shutdown functions, to give
+// in-flight work a chance to complete.
+type DrainWait func()
+
+// DrainWindow returns a DrainWait which always waits for the whole of the
+// drain window.
+func DrainWindow(drainWindow time.Duration) DrainWait {
+	return func() {
+		time.Sleep(drainWindow)
+	}
+}
+
+// maxDrainBackoff caps the interval at which DrainUntil polls isIdle.
+const maxDrainBackoff = 100 * time.Millisecond
+
+// DrainUntil returns a DrainWait which polls isIdle, with an exponential
+// backoff, and returns as soon as it reports true or max has elapsed. This is
+// quicker than a fixed drain window for servers which track their active
+// connections, as they often become idle well before the window is up:
+//
+//	rununtil.AwaitKillSignalsDrainWait(signals, rununtil.DrainUntil(server.Idle, 10*time.Second), NewRunner(logger))
+func DrainUntil(isIdle func() bool, max time.Duration) DrainWait {
+	return func() {
+		deadline := time.Now().Add(max)
+		backoff := time.Millisecond
+		for !isIdle() {
+			remaining := time.Until(deadline)
+			if remaining <= 0 {
+				return
+			}
+			if backoff > remaining {
+				backoff = remaining
+			}
+			time.Sleep(backoff)
+			if backoff < maxDrainBackoff {
+				backoff *= 2
+			}
+		}
+	}
+}
+
+// AwaitKillSignalsDrainWait is the same as AwaitKillSignalsDrain except that,
+// between the drain and the shutdown functions, it blocks on the provided
+// DrainWait rather than for a fixed drain window.
+func AwaitKillSignalsDrainWait(signals []os.Signal, drainWait DrainWait, runnerFuncs ...TwoPhaseRunnerFunc) {
+	wait, release := defaultGroup.listenForKillSignal(signals)
+	defer release()
+
+	var drains []ShutdownFunc
+	runners := make([]RunnerFunc, 0, len(runnerFuncs))
+	for _, runner := range runnerFuncs {
+		runner := runner
+		runners = append(runners, RunnerFunc(func() ShutdownFunc {
+			drain, shutdown := runner()
+			drains = append(drains, noopIfNil(ShutdownFunc(drain)))
+			return shutdown
+		}))
+	}
+	shutdowns := startRunners(runners)
+
+	wait()
+
+	shutdownWithinDefaultTimeout(func() {
+		defer shutdownInReverse(shutdowns)
+		shutdownInReverse(drains)
+		drainWait()
+	})
+}
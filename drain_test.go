@@ -0,0 +1,105 @@
+package rununtil_test
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/mec07/rununtil"
+)
+
+func helperMakeTwoPhaseRunner(idx int, events *[]string) rununtil.TwoPhaseRunnerFunc {
+	return rununtil.TwoPhaseRunnerFunc(func() (rununtil.DrainFunc, rununtil.ShutdownFunc) {
+		drain := rununtil.DrainFunc(func() {
+			*events = append(*events, fmt.Sprintf("drain %d", idx))
+		})
+		shutdown := rununtil.ShutdownFunc(func() {
+			*events = append(*events, fmt.Sprintf("shutdown %d", idx))
+		})
+		return drain, shutdown
+	})
+}
+
+func TestRununtilAwaitKillSignalsDrain(t *testing.T) {
+	var events []string
+	var drainedAt, shutdownAt time.Time
+	timingRunner := rununtil.TwoPhaseRunnerFunc(func() (rununtil.DrainFunc, rununtil.ShutdownFunc) {
+		rununtil.CancelAll()
+		return rununtil.DrainFunc(func() { drainedAt = time.Now() }), rununtil.ShutdownFunc(func() { shutdownAt = time.Now() })
+	})
+	nilRunner := rununtil.TwoPhaseRunnerFunc(func() (rununtil.DrainFunc, rununtil.ShutdownFunc) {
+		return nil, nil
+	})
+
+	rununtil.AwaitKillSignalsDrain(
+		[]os.Signal{syscall.SIGINT},
+		10*time.Millisecond,
+		helperMakeTwoPhaseRunner(1, &events),
+		helperMakeTwoPhaseRunner(2, &events),
+		nilRunner,
+		timingRunner,
+	)
+
+	expected := []string{"drain 2", "drain 1", "shutdown 2", "shutdown 1"}
+	if !reflect.DeepEqual(events, expected) {
+		t.Fatalf("expected events %v, got: %v", expected, events)
+	}
+	if shutdownAt.Sub(drainedAt) < 10*time.Millisecond {
+		t.Fatalf("expected the shutdown to wait for the drain window, waited: %v", shutdownAt.Sub(drainedAt))
+	}
+}
+
+func TestRununtilDrainUntil(t *testing.T) {
+	var polls int32
+	idleAfterPolls := func() bool {
+		return atomic.AddInt32(&polls, 1) > 3
+	}
+
+	start := time.Now()
+	rununtil.DrainUntil(idleAfterPolls, time.Second)()
+
+	if elapsed := time.Since(start); elapsed >= time.Second {
+		t.Fatalf("expected the drain to finish as soon as it was idle, took: %v", elapsed)
+	}
+	if calls := atomic.LoadInt32(&polls); calls != 4 {
+		t.Fatalf("expected isIdle to have been polled until it returned true, got %d polls", calls)
+	}
+}
+
+func TestRununtilDrainUntil_Max(t *testing.T) {
+	neverIdle := func() bool { return false }
+
+	start := time.Now()
+	rununtil.DrainUntil(neverIdle, 20*time.Millisecond)()
+
+	if elapsed := time.Since(start); elapsed < 20*time.Millisecond || elapsed > time.Second {
+		t.Fatalf("expected the drain to give up after the max, took: %v", elapsed)
+	}
+}
+
+func TestRununtilAwaitKillSignalsDrainWait(t *testing.T) {
+	var events []string
+	drainWait := rununtil.DrainWait(func() {
+		events = append(events, "wait")
+	})
+	cancellingRunner := rununtil.TwoPhaseRunnerFunc(func() (rununtil.DrainFunc, rununtil.ShutdownFunc) {
+		rununtil.CancelAll()
+		return nil, nil
+	})
+
+	rununtil.AwaitKillSignalsDrainWait(
+		[]os.Signal{syscall.SIGINT},
+		drainWait,
+		helperMakeTwoPhaseRunner(1, &events),
+		cancellingRunner,
+	)
+
+	expected := []string{"drain 1", "wait", "shutdown 1"}
+	if !reflect.DeepEqual(events, expected) {
+		t.Fatalf("expected events %v, got: %v", expected, events)
+	}
+}
@@ -0,0 +1,75 @@
+package rununtil
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+)
+
+// NewGroupContext creates a new Group, along with a context which is cancelled
+// as soon as the Group starts shutting down, mirroring errgroup.WithContext.
+// The Group is also stopped, in the same way as Stop, if ctx is cancelled. Go
+// routines started with Go on the Group share the returned context:
+//
+//	group, ctx := rununtil.NewGroupContext(context.Background())
+//	group.Go(func(ctx context.Context) error { return consume(ctx, queue) })
+//	group.AwaitKillSignal(NewRunner(logger))
+//	if err := group.Wait(); err != nil {
+//		log.Error().Err(err).Msg("worker failed")
+//	}
+//
+// Like errgroup.WithContext, the Group is single use: unlike one created with
+// NewGroup, it stays shut down, along with its context, once it has stopped.
+func NewGroupContext(ctx context.Context) (*Group, context.Context) {
+	g := NewGroup()
+	g.single = true
+	g.ctx, g.cancelCtx = context.WithCancel(ContextWithGroup(ctx, g))
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			g.Stop()
+		case <-g.ShutdownInitiated():
+		}
+	}()
+
+	return g, g.ctx
+}
+
+// Go runs fn in a new go routine with the Group's context, which is cancelled
+// when the Group starts shutting down. The first error returned by one of the
+// go routines is retained, and it stops the Group in the same way as Stop. A
+// go routine returning context.Canceled after the context has been cancelled
+// is not treated as an error.
+func (g *Group) Go(fn func(ctx context.Context) error) {
+	g.goWG.Add(1)
+	go func() {
+		defer g.goWG.Done()
+
+		ctx := g.context()
+		err := fn(ctx)
+		if err == nil || (ctx.Err() != nil && errors.Cause(err) == context.Canceled) {
+			return
+		}
+		g.recordGoErr(err)
+		g.Stop()
+	}()
+}
+
+// Wait blocks until all of the go routines started with Go have returned, and
+// then returns the first error that one of them returned, if any.
+func (g *Group) Wait() error {
+	g.goWG.Wait()
+
+	g.goErrMux.Lock()
+	defer g.goErrMux.Unlock()
+	return g.goErr
+}
+
+func (g *Group) recordGoErr(err error) {
+	g.goErrMux.Lock()
+	defer g.goErrMux.Unlock()
+	if g.goErr == nil {
+		g.goErr = err
+	}
+}
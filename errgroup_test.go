@@ -0,0 +1,83 @@
+package rununtil_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mec07/rununtil"
+	"github.com/pkg/errors"
+)
+
+func TestGroupGo_ErrorTriggersShutdown(t *testing.T) {
+	group, ctx := rununtil.NewGroupContext(context.Background())
+	errWorker := errors.New("queue unavailable")
+	var hasBeenShutdown bool
+
+	group.Go(func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	group.Go(func(ctx context.Context) error {
+		return errWorker
+	})
+	group.AwaitKillSignal(helperMakeFakeRunner(&hasBeenShutdown))
+
+	if err := group.Wait(); err != errWorker {
+		t.Fatalf("expected the worker error to be returned, got: %v", err)
+	}
+	if !hasBeenShutdown {
+		t.Fatal("expected the shutdown function to have been called")
+	}
+	if ctx.Err() == nil {
+		t.Fatal("expected the group context to have been cancelled")
+	}
+}
+
+func TestNewGroupContext_ParentCancelled(t *testing.T) {
+	parent, cancel := context.WithCancel(context.Background())
+	group, ctx := rununtil.NewGroupContext(parent)
+	var hasBeenShutdown bool
+	cancellingRunner := rununtil.RunnerFunc(func() rununtil.ShutdownFunc {
+		cancel()
+		return nil
+	})
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		group.AwaitKillSignal(helperMakeFakeRunner(&hasBeenShutdown), cancellingRunner)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("expected the group to shutdown when the parent context was cancelled")
+	}
+	if !hasBeenShutdown {
+		t.Fatal("expected the shutdown function to have been called")
+	}
+	if ctx.Err() == nil {
+		t.Fatal("expected the group context to have been cancelled")
+	}
+	if err := group.Wait(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestNewGroupContext_SimulateKillSignal(t *testing.T) {
+	group, ctx := rununtil.NewGroupContext(context.Background())
+	group.Go(func(ctx context.Context) error {
+		<-ctx.Done()
+		return errors.Wrap(ctx.Err(), "worker stopped")
+	})
+
+	group.SimulateKillSignal()
+
+	if err := group.Wait(); err != nil {
+		t.Fatalf("did not expect cancellation to be treated as an error, got: %v", err)
+	}
+	if ctx.Err() == nil {
+		t.Fatal("expected the group context to have been cancelled")
+	}
+}
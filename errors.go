@@ -0,0 +1,169 @@
+package rununtil
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ShutdownFuncE is a function that should be returned by a RunnerFuncE which
+// gracefully shuts down whatever is being run and reports whether it succeeded.
+type ShutdownFuncE func() error
+
+// RunnerFuncE is a nonblocking function that sets off the worker go routines
+// and returns a function which can shutdown those worker go routines,
+// reporting any error that occurred while doing so.
+type RunnerFuncE func() ShutdownFuncE
+
+// ShutdownError is returned when one or more of the shutdown functions failed.
+type ShutdownError struct {
+	// Errors holds the errors returned by the shutdown functions, in the order
+	// that their runners were registered. Each one is wrapped with the index
+	// of its runner; use errors.Cause to get at the original error.
+	Errors []error
+}
+
+func (e *ShutdownError) Error() string {
+	msgs := make([]string, 0, len(e.Errors))
+	for _, err := range e.Errors {
+		msgs = append(msgs, err.Error())
+	}
+	return fmt.Sprintf("%d shutdown function(s) failed: %s", len(e.Errors), strings.Join(msgs, "; "))
+}
+
+// Unwrap returns the errors returned by the shutdown functions, so that they
+// can be inspected with errors.Is and errors.As.
+func (e *ShutdownError) Unwrap() []error {
+	return e.Errors
+}
+
+// Result is the outcome of an await.
+type Result struct {
+	// Signal is the signal which triggered the shutdown, or nil if it was
+	// not triggered by a signal.
+	Signal os.Signal
+	// Reason is what triggered the shutdown.
+	Reason ShutdownReason
+	// ShutdownErr is a *ShutdownError holding the errors returned by the
+	// shutdown functions, or nil if they all succeeded.
+	ShutdownErr error
+	// Duration is how long the shutdown functions took to run.
+	Duration time.Duration
+	// JobErr is the error returned by a Job, or nil if none failed. It is
+	// only set by AwaitResult and the AwaitResult of a Group.
+	JobErr error
+	// PreShutdownErr is the error from the function set with
+	// WithPreShutdownWait, or nil if it succeeded. It is only set by
+	// AwaitResult.
+	PreShutdownErr error
+}
+
+// AwaitKillSignalE runs the provided RunnerFuncEs until it receives a kill
+// signal, SIGINT or SIGTERM, at which point it executes the graceful shutdown
+// functions. If any of the shutdown functions fail, a *ShutdownError
+// containing all of their errors is returned.
+func AwaitKillSignalE(runnerFuncs ...RunnerFuncE) error {
+	return AwaitKillSignalsE(defaultSignals(), runnerFuncs...)
+}
+
+// AwaitKillSignalsE runs the provided RunnerFuncEs until the specified signals
+// have been recieved, at which point it executes the graceful shutdown
+// functions. If any of the shutdown functions fail, a *ShutdownError
+// containing all of their errors is returned.
+func AwaitKillSignalsE(signals []os.Signal, runnerFuncs ...RunnerFuncE) error {
+	return AwaitKillSignalsResult(signals, runnerFuncs...).ShutdownErr
+}
+
+// AwaitKillSignalResult runs the provided RunnerFuncEs until it receives a kill
+// signal, SIGINT or SIGTERM, at which point it executes the graceful shutdown
+// functions and returns the outcome as a Result.
+func AwaitKillSignalResult(runnerFuncs ...RunnerFuncE) Result {
+	return AwaitKillSignalsResult(defaultSignals(), runnerFuncs...)
+}
+
+// AwaitKillSignalsResult runs the provided RunnerFuncEs until the specified
+// signals have been recieved, at which point it executes the graceful shutdown
+// functions in the reverse order to which the runners were registered and
+// returns the outcome as a Result. A shutdown function which panics is
+// treated as having failed: the panic is converted into an error, with its
+// stack trace, which is included in the ShutdownErr. This allows main to make
+// all of its post shutdown decisions from a single value:
+//
+//	result := rununtil.AwaitKillSignalsResult(signals, NewRunner(logger))
+//	log.Info().Msgf("shutdown after %v in %v", result.Reason, result.Duration)
+//	if result.ShutdownErr != nil {
+//		os.Exit(1)
+//	}
+func AwaitKillSignalsResult(signals []os.Signal, runnerFuncs ...RunnerFuncE) Result {
+	c, finish, release := defaultGroup.killSignalChannels(signal.Notify, signals)
+	defer release()
+
+	shutdowns := make([]ShutdownFuncE, 0, len(runnerFuncs))
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			for idx := len(shutdowns) - 1; idx >= 0; idx-- {
+				if shutdowns[idx] != nil {
+					_ = callShutdownE(shutdowns[idx])
+				}
+			}
+			panic(recovered)
+		}
+	}()
+	for _, runner := range runnerFuncs {
+		shutdowns = append(shutdowns, runner())
+	}
+
+	var result Result
+	select {
+	case result.Signal = <-c:
+		result.Reason = ReasonSignal
+	case <-finish:
+		result.Reason = defaultGroup.finishReason(finish)
+	}
+	defaultGroup.initiateShutdown()
+
+	start := time.Now()
+	errs := make([]error, len(shutdowns))
+	shutdownWithinDefaultTimeout(func() {
+		for idx := len(shutdowns) - 1; idx >= 0; idx-- {
+			if shutdowns[idx] != nil {
+				errs[idx] = callShutdownE(shutdowns[idx])
+			}
+		}
+	})
+	result.Duration = time.Since(start)
+	result.ShutdownErr = newShutdownError(errs)
+
+	return result
+}
+
+// callShutdownE executes the shutdown function, converting a panic into an
+// error which carries the stack trace of the panic, so that it is reported in
+// the same way as a shutdown function which failed.
+func callShutdownE(shutdown ShutdownFuncE) (err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = errors.Errorf("shutdown panic: %v", recovered)
+		}
+	}()
+	return shutdown()
+}
+
+// newShutdownError returns a *ShutdownError holding the non-nil errors, or nil
+// if there are none. The index of each error is the index of its runner.
+func newShutdownError(errs []error) error {
+	var failed []error
+	for idx, err := range errs {
+		if err != nil {
+			failed = append(failed, errors.Wrapf(err, "runner %d", idx))
+		}
+	}
+	if len(failed) == 0 {
+		return nil
+	}
+	return &ShutdownError{Errors: failed}
+}
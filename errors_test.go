@@ -0,0 +1,135 @@
+package rununtil_test
+
+import (
+	stderrors "errors"
+	"fmt"
+	"os"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/mec07/rununtil"
+	"github.com/pkg/errors"
+)
+
+func helperMakeFakeRunnerE(hasBeenShutdown *bool, err error) rununtil.RunnerFuncE {
+	return rununtil.RunnerFuncE(func() rununtil.ShutdownFuncE {
+		return rununtil.ShutdownFuncE(func() error {
+			*hasBeenShutdown = true
+			return err
+		})
+	})
+}
+
+func helperMakeCancellingRunnerE() rununtil.RunnerFuncE {
+	return rununtil.RunnerFuncE(func() rununtil.ShutdownFuncE {
+		rununtil.CancelAll()
+		return rununtil.ShutdownFuncE(func() error { return nil })
+	})
+}
+
+func TestRununtilAwaitKillSignalE(t *testing.T) {
+	var hasBeenShutdown bool
+	p, err := os.FindProcess(os.Getpid())
+	if err != nil {
+		t.Fatalf("Unexpected error when finding process: %v", err)
+	}
+
+	go helperSignalOnceListening(t, p, syscall.SIGTERM)
+	err = rununtil.AwaitKillSignalE(helperMakeFakeRunnerE(&hasBeenShutdown, nil))
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !hasBeenShutdown {
+		t.Fatal("expected the shutdown function to have been called")
+	}
+}
+
+func TestRununtilAwaitKillSignalsE_AggregatesErrors(t *testing.T) {
+	errFirst := errors.New("first")
+	errThird := errors.New("third")
+	var hasBeenShutdown1, hasBeenShutdown2, hasBeenShutdown3 bool
+
+	err := rununtil.AwaitKillSignalsE(
+		[]os.Signal{syscall.SIGINT},
+		helperMakeFakeRunnerE(&hasBeenShutdown1, errFirst),
+		helperMakeFakeRunnerE(&hasBeenShutdown2, nil),
+		helperMakeFakeRunnerE(&hasBeenShutdown3, errThird),
+		helperMakeCancellingRunnerE(),
+	)
+
+	if !hasBeenShutdown1 || !hasBeenShutdown2 || !hasBeenShutdown3 {
+		t.Fatal("expected all the shutdown functions to have been called")
+	}
+	var shutdownErr *rununtil.ShutdownError
+	if !stderrors.As(err, &shutdownErr) {
+		t.Fatalf("expected a *ShutdownError, got: %v", err)
+	}
+	if len(shutdownErr.Errors) != 2 {
+		t.Fatalf("expected 2 errors, got: %d", len(shutdownErr.Errors))
+	}
+	if errors.Cause(shutdownErr.Errors[0]) != errFirst {
+		t.Fatalf("expected the first error to be %v, got: %v", errFirst, shutdownErr.Errors[0])
+	}
+	if errors.Cause(shutdownErr.Errors[1]) != errThird {
+		t.Fatalf("expected the second error to be %v, got: %v", errThird, shutdownErr.Errors[1])
+	}
+	if !stderrors.Is(err, errThird) {
+		t.Fatal("expected errors.Is to find the third error")
+	}
+}
+
+func TestRununtilAwaitKillSignalsE_Panic(t *testing.T) {
+	var hasBeenShutdown bool
+	panickingRunner := rununtil.RunnerFuncE(func() rununtil.ShutdownFuncE {
+		return rununtil.ShutdownFuncE(func() error {
+			panic("connection pool corrupted")
+		})
+	})
+
+	err := rununtil.AwaitKillSignalsE(
+		[]os.Signal{syscall.SIGINT},
+		helperMakeFakeRunnerE(&hasBeenShutdown, nil),
+		panickingRunner,
+		helperMakeCancellingRunnerE(),
+	)
+
+	if !hasBeenShutdown {
+		t.Fatal("expected the other shutdown function to have been called")
+	}
+	expected := "1 shutdown function(s) failed: runner 1: shutdown panic: connection pool corrupted"
+	if err == nil || err.Error() != expected {
+		t.Fatalf("expected error %q, got: %v", expected, err)
+	}
+	if stack := fmt.Sprintf("%+v", err.(*rununtil.ShutdownError).Errors[0]); !strings.Contains(stack, "TestRununtilAwaitKillSignalsE_Panic") {
+		t.Fatalf("expected the error to carry the stack trace of the panic, got: %s", stack)
+	}
+}
+
+func TestRununtilAwaitKillSignalsResult(t *testing.T) {
+	errShutdown := errors.New("failed to flush")
+	failingRunner := rununtil.RunnerFuncE(func() rununtil.ShutdownFuncE {
+		return rununtil.ShutdownFuncE(func() error {
+			time.Sleep(5 * time.Millisecond)
+			return errShutdown
+		})
+	})
+
+	result := rununtil.AwaitKillSignalsResult([]os.Signal{syscall.SIGINT}, failingRunner, helperMakeCancellingRunnerE())
+
+	if result.Signal != nil {
+		t.Fatalf("did not expect a signal, got: %v", result.Signal)
+	}
+	if result.Reason != rununtil.ReasonSimulated {
+		t.Fatalf("expected the reason to be %v, got: %v", rununtil.ReasonSimulated, result.Reason)
+	}
+	shutdownErr, ok := result.ShutdownErr.(*rununtil.ShutdownError)
+	if !ok || len(shutdownErr.Errors) != 1 || errors.Cause(shutdownErr.Errors[0]) != errShutdown {
+		t.Fatalf("expected the shutdown error to be returned, got: %v", result.ShutdownErr)
+	}
+	if result.Duration < 5*time.Millisecond {
+		t.Fatalf("expected the duration to be at least 5ms, got: %v", result.Duration)
+	}
+}
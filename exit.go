@@ -0,0 +1,38 @@
+package rununtil
+
+import (
+	"os"
+)
+
+// osExit is used to make the process exit, e.g. when graceful shutdown has not
+// completed in time. It is a variable so that it can be stubbed in tests.
+var osExit = os.Exit
+
+// ExitCodeFunc decides the exit code of the process from the signal which
+// triggered the shutdown, nil if it was triggered by SimulateKillSignal or
+// CancelAll, and the error returned from the graceful shutdown.
+type ExitCodeFunc func(sig os.Signal, shutdownErr error) int
+
+// DefaultExitCode is the ExitCodeFunc used by AwaitKillSignalsExit when none is
+// provided. It returns 1 if the graceful shutdown failed and 0 otherwise.
+func DefaultExitCode(sig os.Signal, shutdownErr error) int {
+	if shutdownErr != nil {
+		return 1
+	}
+	return 0
+}
+
+// AwaitKillSignalsExit runs the provided RunnerFuncEs until the specified
+// signals have been recieved, at which point it executes the graceful shutdown
+// functions in the reverse order to which the runners were registered. It then
+// makes the process exit with the code returned by exitCodeFor, or by
+// DefaultExitCode if exitCodeFor is nil. This centralises the exit code policy,
+// so it should be the last thing called in main.
+func AwaitKillSignalsExit(signals []os.Signal, exitCodeFor ExitCodeFunc, runnerFuncs ...RunnerFuncE) {
+	if exitCodeFor == nil {
+		exitCodeFor = DefaultExitCode
+	}
+
+	result := AwaitKillSignalsResult(signals, runnerFuncs...)
+	osExit(exitCodeFor(result.Signal, result.ShutdownErr))
+}
@@ -0,0 +1,85 @@
+package rununtil_test
+
+import (
+	"os"
+	"syscall"
+	"testing"
+
+	"github.com/mec07/rununtil"
+	"github.com/pkg/errors"
+)
+
+func TestRununtilAwaitKillSignalsExit(t *testing.T) {
+	exitCode := -1
+	restore := rununtil.SetOsExit(func(code int) { exitCode = code })
+	defer restore()
+
+	var gotSig os.Signal
+	var gotErr error
+	exitCodeFor := func(sig os.Signal, shutdownErr error) int {
+		gotSig = sig
+		gotErr = shutdownErr
+		return 143
+	}
+
+	var hasBeenShutdown bool
+	p, err := os.FindProcess(os.Getpid())
+	if err != nil {
+		t.Fatalf("Unexpected error when finding process: %v", err)
+	}
+	go helperSignalOnceListening(t, p, syscall.SIGTERM)
+
+	errShutdown := errors.New("shutdown failed")
+	rununtil.AwaitKillSignalsExit(
+		[]os.Signal{syscall.SIGTERM},
+		exitCodeFor,
+		helperMakeFakeRunnerE(&hasBeenShutdown, errShutdown),
+	)
+
+	if exitCode != 143 {
+		t.Fatalf("expected the process to exit with code 143, got: %d", exitCode)
+	}
+	if gotSig != syscall.SIGTERM {
+		t.Fatalf("expected exitCodeFor to be given SIGTERM, got: %v", gotSig)
+	}
+	if errors.Cause(gotErr.(*rununtil.ShutdownError).Errors[0]) != errShutdown {
+		t.Fatalf("expected exitCodeFor to be given the shutdown error, got: %v", gotErr)
+	}
+}
+
+func TestRununtilAwaitKillSignalsExit_DefaultExitCode(t *testing.T) {
+	table := []struct {
+		name     string
+		err      error
+		expected int
+	}{
+		{
+			name:     "Successful shutdown",
+			expected: 0,
+		},
+		{
+			name:     "Failed shutdown",
+			err:      errors.New("shutdown failed"),
+			expected: 1,
+		},
+	}
+	for _, test := range table {
+		t.Run(test.name, func(t *testing.T) {
+			exitCode := -1
+			restore := rununtil.SetOsExit(func(code int) { exitCode = code })
+			defer restore()
+
+			var hasBeenShutdown bool
+			rununtil.AwaitKillSignalsExit(
+				[]os.Signal{syscall.SIGINT},
+				nil,
+				helperMakeFakeRunnerE(&hasBeenShutdown, test.err),
+				helperMakeCancellingRunnerE(),
+			)
+
+			if exitCode != test.expected {
+				t.Fatalf("expected the process to exit with code %d, got: %d", test.expected, exitCode)
+			}
+		})
+	}
+}
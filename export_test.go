@@ -0,0 +1,77 @@
+package rununtil
+
+import (
+	"io"
+	"os"
+	"time"
+)
+
+// SetOsExit replaces the function used to force the process to exit and
+// returns a function which restores the original.
+func SetOsExit(exit func(code int)) (restore func()) {
+	original := osExit
+	osExit = exit
+	return func() {
+		osExit = original
+	}
+}
+
+// SetReraise replaces the function used to re-raise a signal and returns a
+// function which restores the original.
+func SetReraise(fn func(sig os.Signal) error) (restore func()) {
+	original := reraise
+	reraise = fn
+	return func() {
+		reraise = original
+	}
+}
+
+// SetRandFloat64 replaces the random source used to jitter the restart
+// backoff and returns a function which restores the original.
+func SetRandFloat64(fn func() float64) (restore func()) {
+	original := randFloat64
+	randFloat64 = fn
+	return func() {
+		randFloat64 = original
+	}
+}
+
+// Backoff exposes the backoff method of the RestartPolicy.
+func Backoff(p RestartPolicy, restarts int) time.Duration {
+	return p.backoff(restarts)
+}
+
+// SetSignalStop replaces the function used to stop relaying signals to a
+// channel and returns a function which restores the original.
+func SetSignalStop(fn func(c chan<- os.Signal)) (restore func()) {
+	original := signalStop
+	signalStop = fn
+	return func() {
+		signalStop = original
+	}
+}
+
+// DefaultSignals is the set of kill signals used when none are specified.
+var DefaultSignals = defaultSignals
+
+// ListenForKillSignalWithNotifier exposes the listenForKillSignalWithNotifier
+// method of the Group.
+func ListenForKillSignalWithNotifier(g *Group, notify NotifyFunc, signals []os.Signal) (wait func() os.Signal, release func()) {
+	return g.listenForKillSignalWithNotifier(notify, signals)
+}
+
+// SetStdin replaces the reader watched by WithStdinEOFShutdown and returns a
+// function which restores the original.
+func SetStdin(r io.Reader) (restore func()) {
+	original := stdin
+	stdin = r
+	return func() {
+		stdin = original
+	}
+}
+
+// KilledErrDone is the same as KilledErr except that it also returns the
+// channel which is closed once main has returned.
+func KilledErrDone(main func()) (func() error, <-chan struct{}) {
+	return killedErr(main)
+}
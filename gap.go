@@ -0,0 +1,20 @@
+package rununtil
+
+import (
+	"os"
+	"time"
+)
+
+// AwaitKillSignalsWithGap runs the provided RunnerFuncs until the specified
+// signals have been recieved, at which point it executes the graceful shutdown
+// functions in the reverse order to which the runners were registered, pausing
+// for gap between consecutive shutdown functions (but not before the first or
+// after the last). This is useful for subsystems whose teardown is ordering
+// sensitive in a way that can't be expressed by the registration order alone,
+// e.g. to give Kafka consumers time to commit their offsets before the client
+// is closed.
+// The gap only makes sense when the shutdown functions are executed one after
+// another, so it can't be combined with AwaitKillSignalsParallel.
+func AwaitKillSignalsWithGap(signals []os.Signal, gap time.Duration, runnerFuncs ...RunnerFunc) {
+	Await(runnerFuncs, WithSignals(signals...), WithGap(gap))
+}
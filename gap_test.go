@@ -0,0 +1,35 @@
+package rununtil_test
+
+import (
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/mec07/rununtil"
+)
+
+func helperMakeTimedRunner(shutdownAt *time.Time) rununtil.RunnerFunc {
+	return rununtil.RunnerFunc(func() rununtil.ShutdownFunc {
+		return rununtil.ShutdownFunc(func() {
+			*shutdownAt = time.Now()
+		})
+	})
+}
+
+func TestRununtilAwaitKillSignalsWithGap(t *testing.T) {
+	var first, second time.Time
+	gap := 10 * time.Millisecond
+
+	rununtil.AwaitKillSignalsWithGap(
+		[]os.Signal{syscall.SIGINT},
+		gap,
+		helperMakeTimedRunner(&second),
+		helperMakeTimedRunner(&first),
+		helperMakeCancellingRunner(),
+	)
+
+	if second.Sub(first) < gap {
+		t.Fatalf("expected a gap of at least %v between the shutdowns, got: %v", gap, second.Sub(first))
+	}
+}
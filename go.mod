@@ -4,5 +4,5 @@ go 1.12
 
 require (
 	github.com/google/uuid v1.1.1
-	github.com/pkg/errors v0.8.1
+	github.com/pkg/errors v0.9.1
 )
@@ -0,0 +1,96 @@
+package rununtil
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// NamedRunner is a RunnerFunc with a name, and the names of the runners that
+// it depends on, for use with AwaitKillSignalsGraph.
+type NamedRunner struct {
+	Name      string
+	DependsOn []string
+	Run       RunnerFunc
+}
+
+// AwaitKillSignalsGraph runs the provided NamedRunners until it receives a
+// kill signal, SIGINT or SIGTERM, at which point it executes the graceful
+// shutdown functions. The runners are started after the runners that they
+// depend on and are shutdown before them, e.g. to shutdown both the HTTP
+// server and the worker before the database that they use:
+//
+//	rununtil.AwaitKillSignalsGraph(
+//		rununtil.NamedRunner{Name: "db", Run: NewDB(config)},
+//		rununtil.NamedRunner{Name: "http", DependsOn: []string{"db"}, Run: NewHTTPServer(config)},
+//		rununtil.NamedRunner{Name: "worker", DependsOn: []string{"db"}, Run: NewWorker(config)},
+//	)
+//
+// Runners which don't depend on each other are started in the order that they
+// were registered, and shutdown in the reverse order. If the dependencies
+// can't be satisfied, e.g. because they contain a cycle, an error is returned
+// without starting any of the runners.
+func AwaitKillSignalsGraph(runners ...NamedRunner) error {
+	ordered, err := sortByDependencies(runners)
+	if err != nil {
+		return err
+	}
+
+	defaultGroup.await(nil, ordered)
+	return nil
+}
+
+// sortByDependencies returns the RunnerFuncs of the runners in an order in
+// which every runner comes after the runners that it depends on.
+func sortByDependencies(runners []NamedRunner) ([]RunnerFunc, error) {
+	byName := make(map[string]int, len(runners))
+	for idx, runner := range runners {
+		if _, ok := byName[runner.Name]; ok {
+			return nil, errors.Errorf("duplicate runner name %q", runner.Name)
+		}
+		byName[runner.Name] = idx
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make([]int, len(runners))
+	ordered := make([]RunnerFunc, 0, len(runners))
+	var path []string
+
+	var visit func(idx int) error
+	visit = func(idx int) error {
+		switch state[idx] {
+		case visited:
+			return nil
+		case visiting:
+			return errors.Errorf("dependency cycle: %s -> %s", strings.Join(path, " -> "), runners[idx].Name)
+		}
+
+		state[idx] = visiting
+		path = append(path, runners[idx].Name)
+		for _, dep := range runners[idx].DependsOn {
+			depIdx, ok := byName[dep]
+			if !ok {
+				return errors.Errorf("runner %q depends on unknown runner %q", runners[idx].Name, dep)
+			}
+			if err := visit(depIdx); err != nil {
+				return err
+			}
+		}
+		path = path[:len(path)-1]
+		state[idx] = visited
+
+		ordered = append(ordered, runners[idx].Run)
+		return nil
+	}
+
+	for idx := range runners {
+		if err := visit(idx); err != nil {
+			return nil, err
+		}
+	}
+	return ordered, nil
+}
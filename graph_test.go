@@ -0,0 +1,75 @@
+package rununtil_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/mec07/rununtil"
+)
+
+func TestRununtilAwaitKillSignalsGraph(t *testing.T) {
+	var order []int
+	err := rununtil.AwaitKillSignalsGraph(
+		rununtil.NamedRunner{Name: "http", DependsOn: []string{"db"}, Run: helperMakeOrderedRunner(1, &order)},
+		rununtil.NamedRunner{Name: "db", Run: helperMakeOrderedRunner(2, &order)},
+		rununtil.NamedRunner{Name: "worker", DependsOn: []string{"db"}, Run: helperMakeOrderedRunner(3, &order)},
+		rununtil.NamedRunner{Name: "cancel", DependsOn: []string{"http", "worker"}, Run: helperMakeCancellingRunner()},
+	)
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []int{3, 1, 2}
+	if !reflect.DeepEqual(order, expected) {
+		t.Fatalf("expected shutdown order %v, got: %v", expected, order)
+	}
+}
+
+func TestRununtilAwaitKillSignalsGraph_Invalid(t *testing.T) {
+	var started bool
+	runner := rununtil.RunnerFunc(func() rununtil.ShutdownFunc {
+		started = true
+		return nil
+	})
+	table := []struct {
+		name     string
+		runners  []rununtil.NamedRunner
+		expected string
+	}{
+		{
+			name: "Cycle",
+			runners: []rununtil.NamedRunner{
+				{Name: "a", DependsOn: []string{"b"}, Run: runner},
+				{Name: "b", DependsOn: []string{"c"}, Run: runner},
+				{Name: "c", DependsOn: []string{"a"}, Run: runner},
+			},
+			expected: "dependency cycle: a -> b -> c -> a",
+		},
+		{
+			name: "Unknown dependency",
+			runners: []rununtil.NamedRunner{
+				{Name: "http", DependsOn: []string{"db"}, Run: runner},
+			},
+			expected: `runner "http" depends on unknown runner "db"`,
+		},
+		{
+			name: "Duplicate name",
+			runners: []rununtil.NamedRunner{
+				{Name: "db", Run: runner},
+				{Name: "db", Run: runner},
+			},
+			expected: `duplicate runner name "db"`,
+		},
+	}
+	for _, test := range table {
+		t.Run(test.name, func(t *testing.T) {
+			err := rununtil.AwaitKillSignalsGraph(test.runners...)
+			if err == nil || err.Error() != test.expected {
+				t.Fatalf("expected error %q, got: %v", test.expected, err)
+			}
+			if started {
+				t.Fatal("did not expect any of the runners to be started")
+			}
+		})
+	}
+}
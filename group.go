@@ -0,0 +1,601 @@
+package rununtil
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+)
+
+// cancelEntry is a channel registered with a canceller, which is closed at
+// most once however many times it is cancelled, along with the reason for the
+// first cancellation.
+type cancelEntry struct {
+	c      chan struct{}
+	once   sync.Once
+	reason ShutdownReason
+}
+
+// cancel records reason and closes the channel, unless it has already been
+// cancelled. The reason can be read once the channel has been closed.
+func (e *cancelEntry) cancel(reason ShutdownReason) {
+	e.once.Do(func() {
+		e.reason = reason
+		close(e.c)
+	})
+}
+
+type canceller struct {
+	signals map[string]*cancelEntry
+	mux     sync.Mutex
+	// generation is incremented every time cancelAll is called, so that an
+	// await can tell whether it was cancelled while it was still registering.
+	generation uint64
+	// reason is the reason given to the last call to cancelAll.
+	reason ShutdownReason
+}
+
+// currentGeneration returns the number of times cancelAll has been called.
+func (canc *canceller) currentGeneration() uint64 {
+	canc.mux.Lock()
+	defer canc.mux.Unlock()
+	return canc.generation
+}
+
+// addChannel registers the channel to be closed by cancelAll. If cancelAll has
+// been called since generation was read, i.e. after the await started but
+// before it was registered, the channel is closed straight away, with the
+// reason of that call, so that the cancellation is not lost.
+func (canc *canceller) addChannel(key string, c chan struct{}, generation uint64) {
+	canc.mux.Lock()
+	defer canc.mux.Unlock()
+	entry := &cancelEntry{c: c}
+	if canc.generation != generation {
+		entry.cancel(canc.reason)
+	}
+	canc.signals[key] = entry
+}
+
+func (canc *canceller) removeChannel(key string) {
+	canc.mux.Lock()
+	defer canc.mux.Unlock()
+	delete(canc.signals, key)
+}
+
+// cancelAll closes the channels of the awaits which are registered, recording
+// reason as what stopped them. An await is only registered once it has
+// checked that the generation it read when it started is still current, under
+// the same mutex, so any await which starts after cancelAll has returned reads
+// the new generation and is never cancelled by it, while one which started
+// before is closed straight away by addChannel. The channels stay registered
+// until their awaits remove them, so that reasonFor can still find them.
+func (canc *canceller) cancelAll(reason ShutdownReason) {
+	canc.mux.Lock()
+	defer canc.mux.Unlock()
+	canc.generation++
+	canc.reason = reason
+	for _, entry := range canc.signals {
+		entry.cancel(reason)
+	}
+}
+
+// reasonFor returns the reason that the registered channel c was closed with.
+// It must only be called once c has been closed, and before its await has
+// removed it. ReasonSimulated is returned if c is no longer registered, e.g.
+// after reset.
+func (canc *canceller) reasonFor(c <-chan struct{}) ShutdownReason {
+	canc.mux.Lock()
+	defer canc.mux.Unlock()
+	for _, entry := range canc.signals {
+		if entry.c == c {
+			return entry.reason
+		}
+	}
+	return ReasonSimulated
+}
+
+// reset closes all of the outstanding channels and starts afresh with an
+// empty map.
+func (canc *canceller) reset() {
+	canc.mux.Lock()
+	defer canc.mux.Unlock()
+	canc.generation++
+	canc.reason = ReasonSimulated
+	for _, entry := range canc.signals {
+		entry.cancel(ReasonSimulated)
+	}
+	canc.signals = make(map[string]*cancelEntry)
+}
+
+// Group is an isolated scope for awaits: SimulateKillSignal on a Group only
+// stops the awaits which were started on that Group. This is useful, for
+// example, in tests which run in parallel, where each test can create its own
+// Group so that simulating a kill signal in one test does not tear down the
+// runners of another. Use NewGroup to create a Group.
+//
+// The package level functions, e.g. AwaitKillSignal, use a default Group. The
+// exceptions are the package level SimulateKillSignal and CancelAll, which
+// stop the awaits on every Group.
+type Group struct {
+	canceller canceller
+
+	// stateMux guards initiated, ctx and cancelCtx, which are replaced when a
+	// new await starts on a Group which has finished shutting down
+	stateMux  sync.Mutex
+	initiated chan struct{}
+	ctx       context.Context
+	cancelCtx context.CancelFunc
+	single    bool
+
+	addMux sync.Mutex
+	added  []ShutdownFunc
+
+	crashMux sync.Mutex
+	crashed  bool
+
+	listenersMux sync.Mutex
+	listeners    map[*signalListener]struct{}
+
+	goWG     sync.WaitGroup
+	goErrMux sync.Mutex
+	goErr    error
+
+	simulateDisabled bool
+	options          []Option
+
+	jobMux sync.Mutex
+	jobErr error
+
+	shutdownCtxMux sync.Mutex
+	shutdownCtx    context.Context
+}
+
+// ErrShutdownInitiated is returned by Add when the Group has already started
+// shutting down.
+var ErrShutdownInitiated = errors.New("shutdown has already been initiated")
+
+// GroupOption configures the behaviour of a Group.
+type GroupOption func(*Group)
+
+// WithSimulateDisabled hardens the awaits on the Group against accidental
+// cancellation: SimulateKillSignal on the Group, as well as the package level
+// SimulateKillSignal and CancelAll, are ignored, so the awaits can only be
+// stopped by a real kill signal or by calling Stop on the Group. This is
+// useful in large codebases where tests and production code share packages.
+func WithSimulateDisabled() GroupOption {
+	return func(g *Group) {
+		g.simulateDisabled = true
+	}
+}
+
+// WithOptions sets the Options which are applied, before those passed to the
+// await itself, by the awaits which are built on Await: Await, AwaitResult,
+// AwaitReason, Group.Await, Group.AwaitResult, Group.AwaitKillSignal(s),
+// AwaitKillSignal(s), AwaitKillSignalsSlice, AwaitKillSignalsReturn,
+// AwaitKillSignalStop, AwaitKillSignalsStop, AwaitOnChannel,
+// AwaitKillSignalsWithContext, AwaitKillSignalsWithGap,
+// AwaitKillSignalsWithHooks, AwaitKillSignalsWithLogger,
+// AwaitKillSignalsWithPreShutdown, AwaitKillSignalsWithTimeout,
+// AwaitKillSignalsWithTimeoutFromEnv, AwaitKillSignalsReraise,
+// AwaitKillSignalsParallel, AwaitKillSignalsPhased, AwaitKillSignalsGraph and
+// AwaitKillSignalsProgress. The other awaits, e.g. AwaitKillSignalsInOrder,
+// AwaitKillSignalsWithNotifier, AwaitKillSignalsWithWatchdog,
+// AwaitKillSignalsParallelTimeout, AwaitKillSignalsStats,
+// AwaitKillSignalsStartupTimeout, RunUntilCancel, Start, Group.Start and
+// StartRunners, implement their own shutdown and do not apply them. Combined
+// with SetDefaultGroup this configures the package level awaits too:
+//
+//	rununtil.SetDefaultGroup(rununtil.NewGroup(rununtil.WithOptions(
+//		rununtil.WithLogger(logger),
+//		rununtil.WithTimeout(30*time.Second),
+//	)))
+func WithOptions(opts ...Option) GroupOption {
+	return func(g *Group) {
+		g.options = append(g.options, opts...)
+	}
+}
+
+// NewGroup creates a new Group, configured by the provided GroupOptions.
+func NewGroup(opts ...GroupOption) *Group {
+	g := &Group{
+		canceller: canceller{signals: make(map[string]*cancelEntry)},
+		initiated: make(chan struct{}),
+		listeners: make(map[*signalListener]struct{}),
+	}
+	g.ctx, g.cancelCtx = context.WithCancel(ContextWithGroup(context.Background(), g))
+	for _, opt := range opts {
+		opt(g)
+	}
+	return g
+}
+
+var defaultGroup = NewGroup()
+
+// DefaultGroup returns the Group which is used by the package level
+// functions, e.g. AwaitKillSignal.
+func DefaultGroup() *Group {
+	return defaultGroup
+}
+
+// SetDefaultGroup replaces the Group which is used by the package level
+// functions, e.g. so that they use a Group created with WithOptions. It is
+// not safe to call concurrently with any of the package level functions, so
+// it should be called once during initialisation, before any awaits have
+// started.
+func SetDefaultGroup(g *Group) {
+	defaultGroup = g
+}
+
+// activeGroups counts the awaits which are running on each Group, so that the
+// package level SimulateKillSignal can stop them all. An await is counted
+// from when it starts listening until it has been released, i.e. including
+// while its shutdown functions are running.
+var activeGroups = struct {
+	sync.Mutex
+	counts map[*Group]int
+}{counts: make(map[*Group]int)}
+
+func (g *Group) markActive() {
+	activeGroups.Lock()
+	defer activeGroups.Unlock()
+	activeGroups.counts[g]++
+}
+
+func (g *Group) markInactive() {
+	activeGroups.Lock()
+	defer activeGroups.Unlock()
+	activeGroups.counts[g]--
+	if activeGroups.counts[g] <= 0 {
+		delete(activeGroups.counts, g)
+	}
+}
+
+// otherActiveGroups returns the Groups, other than the default one, which have
+// awaits running.
+func otherActiveGroups() []*Group {
+	activeGroups.Lock()
+	defer activeGroups.Unlock()
+	groups := make([]*Group, 0, len(activeGroups.counts))
+	for g := range activeGroups.counts {
+		if g != defaultGroup {
+			groups = append(groups, g)
+		}
+	}
+	return groups
+}
+
+// AwaitKillSignal runs the provided RunnerFuncs until it receives a kill
+// signal, SIGINT or SIGTERM, or SimulateKillSignal is called on the Group, at
+// which point it executes the graceful shutdown functions in the reverse order
+// to which the runners were registered.
+func (g *Group) AwaitKillSignal(runnerFuncs ...RunnerFunc) {
+	g.AwaitKillSignals(defaultSignals(), runnerFuncs...)
+}
+
+// AwaitKillSignals runs the provided RunnerFuncs until the specified signals
+// have been recieved, or SimulateKillSignal is called on the Group, at which
+// point it executes the graceful shutdown functions in the reverse order to
+// which the runners were registered.
+// A panic in one shutdown function does not prevent the others from being
+// executed; once they have all returned the first panic is propagated.
+func (g *Group) AwaitKillSignals(signals []os.Signal, runnerFuncs ...RunnerFunc) {
+	g.Await(runnerFuncs, WithSignals(signals...))
+}
+
+// Add starts the runner and registers its ShutdownFunc to be executed when
+// the Group shuts down, which allows runners to be added while the Group is
+// already awaiting, e.g. by a plugin system. The added runners are shutdown,
+// in the reverse order to which they were added, by the AwaitKillSignal or
+// AwaitKillSignals await on the Group which stops first, before the runners it
+// was passed. If the Group has already started shutting down, the runner's
+// ShutdownFunc is executed straight away and ErrShutdownInitiated is returned.
+func (g *Group) Add(runner RunnerFunc) error {
+	shutdown := noopIfNil(runner())
+
+	g.addMux.Lock()
+	select {
+	case <-g.ShutdownInitiated():
+		g.addMux.Unlock()
+		shutdown()
+		return ErrShutdownInitiated
+	default:
+	}
+	g.added = append(g.added, shutdown)
+	g.addMux.Unlock()
+
+	return nil
+}
+
+// takeAdded returns the ShutdownFuncs of the runners added with Add, and
+// forgets them so that they are only executed once. It must only be called
+// once the Group has started shutting down, so that no more can be added.
+func (g *Group) takeAdded() []ShutdownFunc {
+	g.addMux.Lock()
+	defer g.addMux.Unlock()
+
+	added := g.added
+	g.added = nil
+	return added
+}
+
+// SimulateKillSignal stops all of the awaits on the Group in the same way that
+// a kill signal would stop them. It is ignored if the Group was created with
+// WithSimulateDisabled.
+func (g *Group) SimulateKillSignal() {
+	if g.simulateDisabled {
+		return
+	}
+	g.stop(ReasonSimulated)
+}
+
+// Stop stops all of the awaits on the Group in the same way that a kill signal
+// would stop them. Unlike SimulateKillSignal it is never ignored, and the
+// awaits report ReasonStopped as the reason for the shutdown.
+func (g *Group) Stop() {
+	g.stop(ReasonStopped)
+}
+
+// stop stops all of the awaits on the Group, which report reason as what
+// triggered their shutdown.
+func (g *Group) stop(reason ShutdownReason) {
+	g.initiateShutdown()
+	g.canceller.cancelAll(reason)
+}
+
+// finishReason returns what closed finish, a channel returned by
+// finishChannel, e.g. ReasonSimulated for SimulateKillSignal or ReasonStopped
+// for Stop. It must only be called once finish has been closed.
+func (g *Group) finishReason(finish <-chan struct{}) ShutdownReason {
+	return g.canceller.reasonFor(finish)
+}
+
+// Shutdown initiates a graceful shutdown of the Group, in the same way as
+// Stop. It is intended for code which retrieved the Group with FromContext,
+// e.g. on an unrecoverable error deep in a request handler.
+func (g *Group) Shutdown() {
+	g.Stop()
+}
+
+// ShutdownContext returns a context which expires at the deadline of the
+// Group's shutdown in progress, e.g. the one set by WithTimeout, so that a
+// ShutdownFunc can bound a graceful stop by it. If no await on the Group is
+// shutting down with a deadline, the context is bounded by
+// DefaultShutdownTimeout instead, or by nothing if that is zero. The returned
+// CancelFunc must be called once the shutdown is done with the context.
+func (g *Group) ShutdownContext() (context.Context, context.CancelFunc) {
+	g.shutdownCtxMux.Lock()
+	ctx := g.shutdownCtx
+	g.shutdownCtxMux.Unlock()
+
+	if ctx != nil {
+		if _, ok := ctx.Deadline(); ok {
+			return context.WithCancel(ctx)
+		}
+	}
+	if DefaultShutdownTimeout > 0 {
+		return context.WithTimeout(context.Background(), DefaultShutdownTimeout)
+	}
+	return context.WithCancel(context.Background())
+}
+
+// setShutdownContext records ctx as the context of the Group's shutdown in
+// progress, for ShutdownContext. It returns a function which clears it again.
+func (g *Group) setShutdownContext(ctx context.Context) func() {
+	g.shutdownCtxMux.Lock()
+	g.shutdownCtx = ctx
+	g.shutdownCtxMux.Unlock()
+
+	return func() {
+		g.shutdownCtxMux.Lock()
+		if g.shutdownCtx == ctx {
+			g.shutdownCtx = nil
+		}
+		g.shutdownCtxMux.Unlock()
+	}
+}
+
+// ActiveCount returns the number of awaits on the Group which are still
+// running. An await stops being counted once it has returned, so a test
+// teardown can assert that it is zero to catch leaked runners.
+func (g *Group) ActiveCount() int {
+	activeGroups.Lock()
+	defer activeGroups.Unlock()
+	return activeGroups.counts[g]
+}
+
+// ActiveCount returns the number of awaits on the default Group which are
+// still running.
+func ActiveCount() int {
+	return defaultGroup.ActiveCount()
+}
+
+// ShutdownInitiated returns a channel which is closed as soon as a shutdown
+// has been initiated on the Group, i.e. when one of its awaits has received a
+// kill signal or SimulateKillSignal has been called. This allows auxiliary go
+// routines to react to the shutdown independently of the awaits.
+// Once closed it stays closed until the Group has finished shutting down and
+// a new await is started on it, at which point ShutdownInitiated returns a new
+// channel.
+func (g *Group) ShutdownInitiated() <-chan struct{} {
+	g.stateMux.Lock()
+	defer g.stateMux.Unlock()
+	return g.initiated
+}
+
+// ShutdownInitiated returns a channel which is closed as soon as a shutdown
+// has been initiated on the default Group, i.e. when a kill signal has been
+// received or SimulateKillSignal or CancelAll has been called.
+func ShutdownInitiated() <-chan struct{} {
+	return defaultGroup.ShutdownInitiated()
+}
+
+// initiateShutdown marks the Group as shutting down and cancels its context.
+func (g *Group) initiateShutdown() {
+	g.stateMux.Lock()
+	defer g.stateMux.Unlock()
+
+	select {
+	case <-g.initiated:
+	default:
+		close(g.initiated)
+		g.cancelCtx()
+	}
+}
+
+// context returns the Group's context, which is cancelled as soon as the
+// Group starts shutting down.
+func (g *Group) context() context.Context {
+	g.stateMux.Lock()
+	defer g.stateMux.Unlock()
+	return g.ctx
+}
+
+// resetIfShutDown gives the Group a new ShutdownInitiated channel and context
+// if a previous shutdown has finished, i.e. it has been initiated and none of
+// the Group's awaits are still running, so that a new await can reuse the
+// Group. Groups created with NewGroupContext are never reset.
+func (g *Group) resetIfShutDown() {
+	g.stateMux.Lock()
+	defer g.stateMux.Unlock()
+
+	if g.single || g.ActiveCount() > 0 {
+		return
+	}
+	select {
+	case <-g.initiated:
+		g.initiated = make(chan struct{})
+		g.ctx, g.cancelCtx = context.WithCancel(ContextWithGroup(context.Background(), g))
+	default:
+	}
+}
+
+// SimulateKillSignal stops all of the awaits on the default Group, and those
+// on every other Group, in the same way that a kill signal would stop them.
+// Groups created with WithSimulateDisabled are not affected. It is equivalent
+// to CancelAll.
+func SimulateKillSignal() {
+	defaultGroup.SimulateKillSignal()
+	for _, g := range otherActiveGroups() {
+		g.SimulateKillSignal()
+	}
+}
+
+// SimulateKillSignalAndWait is the same as SimulateKillSignal except that it
+// blocks until all of the awaits which it stopped have returned, i.e. until
+// their shutdown functions have been executed, so that a test can assert on
+// the results of the shutdown straight away rather than sleeping. An error is
+// returned if any of them are still running once the timeout has elapsed.
+func SimulateKillSignalAndWait(timeout time.Duration) error {
+	SimulateKillSignal()
+
+	deadline := time.Now().Add(timeout)
+	backoff := time.Millisecond
+	for {
+		count := stoppableActiveCount()
+		if count == 0 {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return errors.Errorf("%d await(s) still running %v after simulating a kill signal", count, timeout)
+		}
+		time.Sleep(backoff)
+		if backoff < 10*time.Millisecond {
+			backoff *= 2
+		}
+	}
+}
+
+// stoppableActiveCount returns the number of awaits which are running on the
+// Groups that SimulateKillSignal can stop, i.e. those not created with
+// WithSimulateDisabled.
+func stoppableActiveCount() int {
+	activeGroups.Lock()
+	defer activeGroups.Unlock()
+	var count int
+	for g, n := range activeGroups.counts {
+		if !g.simulateDisabled {
+			count += n
+		}
+	}
+	return count
+}
+
+// listenForKillSignal starts listening for the specified signals and for
+// SimulateKillSignal, and returns a function which blocks until one of them
+// arrives and then marks the Group as shutting down. The wait function returns
+// the signal which was received, or nil if SimulateKillSignal was called.
+// The release function must be called once the await returns, so that it is
+// no longer counted as active.
+func (g *Group) listenForKillSignal(signals []os.Signal) (wait func() os.Signal, release func()) {
+	return g.listenForKillSignalWithNotifier(signal.Notify, signals)
+}
+
+// listenForKillSignalWithNotifier is the same as listenForKillSignal except
+// that it uses the provided NotifyFunc instead of signal.Notify.
+func (g *Group) listenForKillSignalWithNotifier(notify NotifyFunc, signals []os.Signal) (wait func() os.Signal, release func()) {
+	c, finish, release := g.killSignalChannels(notify, signals)
+
+	wait = func() os.Signal {
+		// Wait for a kill signal
+		var sig os.Signal
+		select {
+		case sig = <-c:
+		case <-finish:
+		}
+		g.initiateShutdown()
+		return sig
+	}
+	return wait, release
+}
+
+// killSignalChannels starts listening for the specified signals, using the
+// provided NotifyFunc, and for SimulateKillSignal. It returns the channel
+// that the signals are delivered on, the channel that is closed by
+// SimulateKillSignal and the function which must be called once the await
+// returns. The caller is responsible for calling initiateShutdown once it has
+// received a kill signal.
+func (g *Group) killSignalChannels(notify NotifyFunc, signals []os.Signal) (<-chan os.Signal, <-chan struct{}, func()) {
+	return g.killSignalChannelsSince(notify, signals, g.canceller.currentGeneration())
+}
+
+// killSignalChannelsSince is the same as killSignalChannels except that the
+// channel for SimulateKillSignal is closed straight away if SimulateKillSignal
+// has been called since the canceller was at the given generation.
+func (g *Group) killSignalChannelsSince(notify NotifyFunc, signals []os.Signal, generation uint64) (<-chan os.Signal, <-chan struct{}, func()) {
+	c := make(chan os.Signal, 1)
+	listener := g.addListener(notify, c, signals)
+
+	finish, releaseFinish := g.finishChannelSince(generation)
+	return c, finish, func() {
+		releaseFinish()
+		g.removeListener(listener)
+	}
+}
+
+// finishChannel returns a channel which is closed by SimulateKillSignal, and
+// a function which removes it from the Group once the await returns. The
+// caller is responsible for calling initiateShutdown once it has been closed.
+func (g *Group) finishChannel() (<-chan struct{}, func()) {
+	return g.finishChannelSince(g.canceller.currentGeneration())
+}
+
+// finishChannelSince is the same as finishChannel except that the channel is
+// closed straight away if SimulateKillSignal has been called since the
+// canceller was at the given generation.
+func (g *Group) finishChannelSince(generation uint64) (<-chan struct{}, func()) {
+	g.resetIfShutDown()
+
+	finish := make(chan struct{})
+	key := uuid.New().String()
+	g.canceller.addChannel(key, finish, generation)
+	g.markActive()
+
+	return finish, func() {
+		g.canceller.removeChannel(key)
+		g.markInactive()
+	}
+}
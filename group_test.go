@@ -0,0 +1,597 @@
+package rununtil_test
+
+import (
+	"os"
+	"reflect"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/mec07/rununtil"
+)
+
+// helperMakeStartedRunner returns a runner which closes the started channel
+// once it has been run, i.e. once its await is listening for kill signals.
+func helperMakeStartedRunner(started chan struct{}, hasBeenShutdown *bool) rununtil.RunnerFunc {
+	return rununtil.RunnerFunc(func() rununtil.ShutdownFunc {
+		close(started)
+		return rununtil.ShutdownFunc(func() {
+			*hasBeenShutdown = true
+		})
+	})
+}
+
+func TestGroupSimulateKillSignal_Isolated(t *testing.T) {
+	groupA := rununtil.NewGroup()
+	groupB := rununtil.NewGroup()
+
+	var hasBeenShutdownA, hasBeenShutdownB bool
+	startedA := make(chan struct{})
+	startedB := make(chan struct{})
+	doneA := make(chan struct{})
+	doneB := make(chan struct{})
+	go func() {
+		groupA.AwaitKillSignal(helperMakeStartedRunner(startedA, &hasBeenShutdownA))
+		close(doneA)
+	}()
+	go func() {
+		groupB.AwaitKillSignal(helperMakeStartedRunner(startedB, &hasBeenShutdownB))
+		close(doneB)
+	}()
+	<-startedA
+	<-startedB
+
+	groupB.SimulateKillSignal()
+	select {
+	case <-doneB:
+	case <-time.After(time.Second):
+		t.Fatal("expected group B to have been stopped")
+	}
+	if !hasBeenShutdownB {
+		t.Fatal("expected the shutdown function of group B to have been called")
+	}
+
+	// the other group should not affect group A
+	select {
+	case <-doneA:
+		t.Fatal("did not expect group A to have been stopped")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	groupA.SimulateKillSignal()
+	select {
+	case <-doneA:
+	case <-time.After(time.Second):
+		t.Fatal("expected group A to have been stopped")
+	}
+	if !hasBeenShutdownA {
+		t.Fatal("expected the shutdown function of group A to have been called")
+	}
+}
+
+func TestGroupShutdownInitiated(t *testing.T) {
+	group := rununtil.NewGroup()
+
+	var initiatedBeforeShutdown bool
+	runner := rununtil.RunnerFunc(func() rununtil.ShutdownFunc {
+		return rununtil.ShutdownFunc(func() {
+			select {
+			case <-group.ShutdownInitiated():
+				initiatedBeforeShutdown = true
+			default:
+			}
+		})
+	})
+	started := make(chan struct{})
+	var hasBeenShutdown bool
+
+	done := make(chan struct{})
+	go func() {
+		group.AwaitKillSignal(runner, helperMakeStartedRunner(started, &hasBeenShutdown))
+		close(done)
+	}()
+	<-started
+
+	select {
+	case <-group.ShutdownInitiated():
+		t.Fatal("did not expect a shutdown to have been initiated yet")
+	default:
+	}
+
+	group.SimulateKillSignal()
+	select {
+	case <-group.ShutdownInitiated():
+	case <-time.After(time.Second):
+		t.Fatal("expected a shutdown to have been initiated")
+	}
+	<-done
+	if !initiatedBeforeShutdown {
+		t.Fatal("expected the shutdown to have been initiated before the shutdown functions were called")
+	}
+}
+
+func TestGroupShutdownInitiated_Signal(t *testing.T) {
+	group := rununtil.NewGroup()
+	var notifier fakeNotifier
+	wait, release := rununtil.ListenForKillSignalWithNotifier(group, notifier.notify, []os.Signal{syscall.SIGTERM})
+	defer release()
+
+	notifier.c <- syscall.SIGTERM
+	wait()
+
+	select {
+	case <-group.ShutdownInitiated():
+	default:
+		t.Fatal("expected a shutdown to have been initiated")
+	}
+}
+
+func TestGroupSimulateKillSignal_Concurrent(t *testing.T) {
+	group := rununtil.NewGroup()
+	started := make(chan struct{})
+	var hasBeenShutdown bool
+
+	done := make(chan struct{})
+	go func() {
+		group.AwaitKillSignal(helperMakeStartedRunner(started, &hasBeenShutdown))
+		close(done)
+	}()
+	<-started
+
+	var wg sync.WaitGroup
+	for idx := 0; idx < 50; idx++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for call := 0; call < 20; call++ {
+				group.SimulateKillSignal()
+			}
+		}()
+	}
+	wg.Wait()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("expected the await to have been stopped")
+	}
+	if !hasBeenShutdown {
+		t.Fatal("expected the shutdown function to have been called")
+	}
+}
+
+func TestGroupActiveCount(t *testing.T) {
+	group := rununtil.NewGroup()
+	if count := group.ActiveCount(); count != 0 {
+		t.Fatalf("expected no active awaits, got: %d", count)
+	}
+
+	started := make(chan struct{})
+	var hasBeenShutdown bool
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		group.AwaitKillSignal(helperMakeStartedRunner(started, &hasBeenShutdown))
+	}()
+	<-started
+
+	if count := group.ActiveCount(); count != 1 {
+		t.Fatalf("expected one active await, got: %d", count)
+	}
+
+	group.SimulateKillSignal()
+	<-done
+
+	if count := group.ActiveCount(); count != 0 {
+		t.Fatalf("expected no active awaits after the shutdown, got: %d", count)
+	}
+}
+
+func TestGroupActiveCount_WhileShuttingDown(t *testing.T) {
+	group := rununtil.NewGroup()
+	shuttingDown := make(chan struct{})
+	release := make(chan struct{})
+	blockingRunner := rununtil.RunnerFunc(func() rununtil.ShutdownFunc {
+		return func() {
+			close(shuttingDown)
+			<-release
+		}
+	})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		group.AwaitKillSignal(blockingRunner)
+	}()
+	for group.ActiveCount() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	group.Stop()
+	<-shuttingDown
+	if count := group.ActiveCount(); count != 1 {
+		t.Fatalf("expected the await to be counted while its shutdown function is running, got: %d", count)
+	}
+
+	close(release)
+	<-done
+	if count := group.ActiveCount(); count != 0 {
+		t.Fatalf("expected no active awaits after the shutdown, got: %d", count)
+	}
+}
+
+func TestGroupActiveCount_Signal(t *testing.T) {
+	group := rununtil.NewGroup()
+	var notifier fakeNotifier
+	wait, release := rununtil.ListenForKillSignalWithNotifier(group, notifier.notify, []os.Signal{syscall.SIGTERM})
+
+	notifier.c <- syscall.SIGTERM
+	wait()
+	if count := group.ActiveCount(); count != 1 {
+		t.Fatalf("expected the await to be active until it has been released, got: %d", count)
+	}
+	release()
+
+	if count := group.ActiveCount(); count != 0 {
+		t.Fatalf("expected the await to no longer be active once it received a signal, got: %d", count)
+	}
+}
+
+func TestGroupAdd(t *testing.T) {
+	group := rununtil.NewGroup()
+	var order []int
+	addingRunner := rununtil.RunnerFunc(func() rununtil.ShutdownFunc {
+		if err := group.Add(helperMakeOrderedRunner(2, &order)); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		if err := group.Add(helperMakeOrderedRunner(3, &order)); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		group.SimulateKillSignal()
+		return nil
+	})
+
+	group.AwaitKillSignals([]os.Signal{syscall.SIGINT}, helperMakeOrderedRunner(1, &order), addingRunner)
+
+	expected := []int{3, 2, 1}
+	if !reflect.DeepEqual(order, expected) {
+		t.Fatalf("expected shutdown order %v, got: %v", expected, order)
+	}
+}
+
+func TestGroupAdd_AfterShutdown(t *testing.T) {
+	group := rununtil.NewGroup()
+	group.SimulateKillSignal()
+
+	var hasBeenShutdown bool
+	err := group.Add(helperMakeFakeRunner(&hasBeenShutdown))
+
+	if err != rununtil.ErrShutdownInitiated {
+		t.Fatalf("expected ErrShutdownInitiated, got: %v", err)
+	}
+	if !hasBeenShutdown {
+		t.Fatal("expected the added runner to have been shutdown straight away")
+	}
+}
+
+func TestGroupSimulateKillSignal_RepeatedAwaits(t *testing.T) {
+	group := rununtil.NewGroup()
+	for idx := 0; idx < 10000; idx++ {
+		started := make(chan struct{})
+		done := make(chan struct{})
+		var hasBeenShutdown bool
+		go func() {
+			defer close(done)
+			group.AwaitKillSignal(helperMakeStartedRunner(started, &hasBeenShutdown))
+		}()
+		<-started
+
+		group.SimulateKillSignal()
+
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatalf("expected await %d to have been stopped", idx)
+		}
+		if !hasBeenShutdown {
+			t.Fatalf("expected await %d to have been shutdown", idx)
+		}
+	}
+}
+
+func TestGroupSimulateKillSignal_WhileRegistering(t *testing.T) {
+	group := rununtil.NewGroup()
+	// the cancellation arrives after the await has started listening for
+	// signals but before it has registered with the Group
+	notify := func(c chan<- os.Signal, sig ...os.Signal) {
+		group.SimulateKillSignal()
+	}
+	wait, release := rununtil.ListenForKillSignalWithNotifier(group, notify, []os.Signal{syscall.SIGTERM})
+	defer release()
+
+	returned := make(chan struct{})
+	go func() {
+		defer close(returned)
+		wait()
+	}()
+
+	select {
+	case <-returned:
+	case <-time.After(time.Second):
+		t.Fatal("expected the cancellation not to have been lost")
+	}
+}
+
+func TestGroupWithSimulateDisabled(t *testing.T) {
+	group := rununtil.NewGroup(rununtil.WithSimulateDisabled())
+
+	var hasBeenShutdown bool
+	started := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		group.AwaitKillSignal(helperMakeStartedRunner(started, &hasBeenShutdown))
+		close(done)
+	}()
+	<-started
+
+	group.SimulateKillSignal()
+	rununtil.SimulateKillSignal()
+	rununtil.CancelAll()
+	select {
+	case <-done:
+		t.Fatal("did not expect a simulated kill signal to stop the group")
+	case <-group.ShutdownInitiated():
+		t.Fatal("did not expect a simulated kill signal to initiate a shutdown")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	group.Stop()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("expected Stop to have stopped the group")
+	}
+	if !hasBeenShutdown {
+		t.Fatal("expected the shutdown function to have been called")
+	}
+}
+
+func TestGroupStart_Independent(t *testing.T) {
+	serviceA := rununtil.NewGroup()
+	serviceB := rununtil.NewGroup()
+	var hasBeenShutdownA, hasBeenShutdownB bool
+
+	stopA := serviceA.Start(helperMakeFakeRunner(&hasBeenShutdownA))
+	stopB := serviceB.Start(helperMakeFakeRunner(&hasBeenShutdownB))
+
+	stopA()
+	if !hasBeenShutdownA {
+		t.Fatal("expected service A to have been shutdown")
+	}
+	if hasBeenShutdownB {
+		t.Fatal("did not expect stopping service A to shutdown service B")
+	}
+	if serviceB.ActiveCount() != 1 {
+		t.Fatal("expected service B to still be running")
+	}
+
+	stopB()
+	if !hasBeenShutdownB {
+		t.Fatal("expected service B to have been shutdown")
+	}
+}
+
+func TestRununtilSimulateKillSignal_StopsAllGroups(t *testing.T) {
+	serviceA := rununtil.NewGroup()
+	serviceB := rununtil.NewGroup()
+	var hasBeenShutdownA, hasBeenShutdownB bool
+	stopA := serviceA.Start(helperMakeFakeRunner(&hasBeenShutdownA))
+	stopB := serviceB.Start(helperMakeFakeRunner(&hasBeenShutdownB))
+
+	rununtil.SimulateKillSignal()
+
+	for name, group := range map[string]*rununtil.Group{"A": serviceA, "B": serviceB} {
+		select {
+		case <-group.ShutdownInitiated():
+		default:
+			t.Fatalf("expected the shutdown of service %s to have been initiated", name)
+		}
+	}
+
+	// the StopFuncs wait for the shutdowns which have already been initiated
+	stopA()
+	stopB()
+	if !hasBeenShutdownA || !hasBeenShutdownB {
+		t.Fatal("expected both services to have been shutdown")
+	}
+	for name, group := range map[string]*rununtil.Group{"A": serviceA, "B": serviceB} {
+		if group.ActiveCount() != 0 {
+			t.Fatalf("expected the await of service %s to have returned", name)
+		}
+	}
+}
+
+func TestRununtilSetDefaultGroup(t *testing.T) {
+	original := rununtil.DefaultGroup()
+	defer rununtil.SetDefaultGroup(original)
+	logger := &recordingLogger{}
+	group := rununtil.NewGroup(rununtil.WithOptions(rununtil.WithLogger(logger)))
+
+	rununtil.SetDefaultGroup(group)
+	if rununtil.DefaultGroup() != group {
+		t.Fatal("expected the default group to have been replaced")
+	}
+	var hasBeenShutdown bool
+	rununtil.AwaitKillSignal(helperMakeFakeRunner(&hasBeenShutdown), helperMakeCancellingRunner())
+
+	if !hasBeenShutdown {
+		t.Fatal("expected the shutdown function to have been called")
+	}
+	if len(logger.messages) == 0 || logger.messages[len(logger.messages)-1] != "shutdown complete" {
+		t.Fatalf("expected the package level await to use the options of the new default group, got: %q", logger.messages)
+	}
+}
+
+func TestRununtilSimulateKillSignalAndWait(t *testing.T) {
+	group := rununtil.NewGroup()
+	started := make(chan struct{})
+	var hasBeenShutdown bool
+	slowRunner := rununtil.RunnerFunc(func() rununtil.ShutdownFunc {
+		close(started)
+		return rununtil.ShutdownFunc(func() {
+			time.Sleep(20 * time.Millisecond)
+			hasBeenShutdown = true
+		})
+	})
+	go group.AwaitKillSignal(slowRunner)
+	<-started
+
+	if err := rununtil.SimulateKillSignalAndWait(time.Second); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !hasBeenShutdown {
+		t.Fatal("expected the shutdown function to have returned before SimulateKillSignalAndWait")
+	}
+}
+
+func TestRununtilSimulateKillSignalAndWait_Timeout(t *testing.T) {
+	group := rununtil.NewGroup()
+	started := make(chan struct{})
+	release := make(chan struct{})
+	blockingRunner := rununtil.RunnerFunc(func() rununtil.ShutdownFunc {
+		close(started)
+		return rununtil.ShutdownFunc(func() {
+			<-release
+		})
+	})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		group.AwaitKillSignal(blockingRunner)
+	}()
+	<-started
+
+	if err := rununtil.SimulateKillSignalAndWait(10 * time.Millisecond); err == nil {
+		t.Fatal("expected an error as the shutdown function was still running")
+	}
+	close(release)
+	<-done
+}
+
+func TestGroupShutdownContext(t *testing.T) {
+	group := rununtil.NewGroup()
+	hasDeadline := make(chan bool, 1)
+	shutdownCtx := func() rununtil.ShutdownFunc {
+		return func() {
+			ctx, cancel := group.ShutdownContext()
+			defer cancel()
+			_, ok := ctx.Deadline()
+			hasDeadline <- ok
+		}
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		group.AwaitResult([]rununtil.RunnerFunc{shutdownCtx}, rununtil.WithTimeout(time.Minute))
+	}()
+	for group.ActiveCount() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	group.Stop()
+	<-done
+
+	if !<-hasDeadline {
+		t.Fatal("expected the ShutdownContext to carry the deadline of the await's timeout")
+	}
+
+	ctx, cancel := group.ShutdownContext()
+	defer cancel()
+	if _, ok := ctx.Deadline(); ok && rununtil.DefaultShutdownTimeout == 0 {
+		t.Fatal("expected no deadline once the shutdown has finished")
+	}
+}
+
+func TestGroup_ReusedAfterShutdown(t *testing.T) {
+	group := rununtil.NewGroup()
+	readiness := group.Readiness()
+	var firstShutdown bool
+	group.Start(helperMakeFakeRunner(&firstShutdown))()
+
+	select {
+	case <-group.ShutdownInitiated():
+	default:
+		t.Fatal("expected the first shutdown to have been initiated")
+	}
+
+	var secondShutdown bool
+	started := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		group.AwaitKillSignal(helperMakeStartedRunner(started, &secondShutdown))
+	}()
+	<-started
+
+	select {
+	case <-group.ShutdownInitiated():
+		t.Fatal("did not expect a shutdown to have been initiated for the new await")
+	default:
+	}
+	if !readiness.IsReady() {
+		t.Fatal("expected to be ready again once the new await had started")
+	}
+	var addedShutdown bool
+	if err := group.Add(helperMakeFakeRunner(&addedShutdown)); err != nil {
+		t.Fatalf("unexpected error adding to the reused group: %v", err)
+	}
+
+	group.Stop()
+	<-done
+	if !secondShutdown || !addedShutdown {
+		t.Fatal("expected the new await to have shutdown its runners and the added one")
+	}
+}
+
+func TestGroup_NotResetWhileShuttingDown(t *testing.T) {
+	group := rununtil.NewGroup()
+	readiness := group.Readiness()
+	shuttingDown := make(chan struct{})
+	release := make(chan struct{})
+	blockingRunner := rununtil.RunnerFunc(func() rununtil.ShutdownFunc {
+		return func() {
+			close(shuttingDown)
+			<-release
+		}
+	})
+	firstDone := make(chan struct{})
+	go func() {
+		defer close(firstDone)
+		group.AwaitKillSignal(blockingRunner)
+	}()
+	for group.ActiveCount() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	group.Stop()
+	<-shuttingDown
+
+	// a new await while the first one is still draining must not make the
+	// Group look as though it is no longer shutting down
+	started := make(chan struct{})
+	var hasBeenShutdown bool
+	secondDone := make(chan struct{})
+	go func() {
+		defer close(secondDone)
+		group.AwaitKillSignal(helperMakeStartedRunner(started, &hasBeenShutdown))
+	}()
+	<-started
+
+	if readiness.IsReady() {
+		t.Fatal("did not expect to be ready while the first await was still shutting down")
+	}
+
+	close(release)
+	<-firstDone
+	group.Stop()
+	<-secondDone
+}
@@ -0,0 +1,110 @@
+// Package grpcrun provides a rununtil runner for gRPC servers. It depends only
+// on the methods of *grpc.Server that it uses, rather than on grpc itself, so
+// that the core of rununtil stays free of heavy dependencies.
+package grpcrun
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"time"
+
+	"github.com/mec07/rununtil"
+	"github.com/pkg/errors"
+)
+
+// DefaultStopTimeout is how long GRPCServer waits for the in-flight RPCs to
+// complete before forcibly stopping the server, if the await's shutdown has no
+// deadline of its own and rununtil.DefaultShutdownTimeout is zero.
+const DefaultStopTimeout = 30 * time.Second
+
+// Server is the subset of the methods of *grpc.Server which are used to run
+// and shutdown a gRPC server.
+type Server interface {
+	Serve(lis net.Listener) error
+	GracefulStop()
+	Stop()
+}
+
+// GRPCServer returns a RunnerFunc which runs srv.Serve(lis) in a go routine,
+// and whose ShutdownFunc gracefully shuts the server down with
+// srv.GracefulStop, falling back to srv.Stop if the in-flight RPCs have not
+// completed by the deadline of the default Group's ShutdownContext, or within
+// DefaultStopTimeout if it has none:
+//
+//	lis, err := net.Listen("tcp", ":9090")
+//	if err != nil {
+//		log.Fatal().Err(err).Msg("failed to listen")
+//	}
+//	rununtil.AwaitKillSignal(grpcrun.GRPCServer(grpc.NewServer(), lis))
+//
+// If the server fails, the error is reported on stderr and the default Group
+// is stopped.
+func GRPCServer(srv Server, lis net.Listener) rununtil.RunnerFunc {
+	return GroupGRPCServer(rununtil.DefaultGroup(), srv, lis)
+}
+
+// GroupGRPCServer is the same as GRPCServer except that it is bound to g: the
+// fallback to srv.Stop happens at the deadline of g's ShutdownContext, and a
+// server failure stops g.
+func GroupGRPCServer(g *rununtil.Group, srv Server, lis net.Listener) rununtil.RunnerFunc {
+	return serve(g, srv, lis, func() (context.Context, context.CancelFunc) {
+		ctx, cancel := g.ShutdownContext()
+		if _, ok := ctx.Deadline(); ok {
+			return ctx, cancel
+		}
+		cancel()
+		return context.WithTimeout(context.Background(), DefaultStopTimeout)
+	})
+}
+
+// GRPCServerWithTimeout is the same as GRPCServer except that it waits up to
+// timeout for the in-flight RPCs to complete before forcibly stopping the
+// server, whatever the deadline of the await's shutdown.
+func GRPCServerWithTimeout(srv Server, lis net.Listener, timeout time.Duration) rununtil.RunnerFunc {
+	return serve(rununtil.DefaultGroup(), srv, lis, func() (context.Context, context.CancelFunc) {
+		return context.WithTimeout(context.Background(), timeout)
+	})
+}
+
+// serve returns a RunnerFunc which runs srv.Serve(lis) in a go routine, and
+// whose ShutdownFunc gracefully stops srv, forcibly stopping it once the
+// context returned by stopContext is done.
+func serve(g *rununtil.Group, srv Server, lis net.Listener, stopContext func() (context.Context, context.CancelFunc)) rununtil.RunnerFunc {
+	return func() rununtil.ShutdownFunc {
+		stopping := make(chan struct{})
+		go func() {
+			err := srv.Serve(lis)
+			select {
+			case <-stopping:
+				// the error is expected once the server has been stopped
+				return
+			default:
+			}
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "ERROR: %+v\n", errors.Wrapf(err, "serving gRPC on %v, initiating shutdown", lis.Addr()))
+				g.Stop()
+			}
+		}()
+
+		return func() {
+			close(stopping)
+
+			stopped := make(chan struct{})
+			go func() {
+				srv.GracefulStop()
+				close(stopped)
+			}()
+
+			ctx, cancel := stopContext()
+			defer cancel()
+			select {
+			case <-stopped:
+			case <-ctx.Done():
+				srv.Stop()
+				<-stopped
+			}
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package grpcrun_test
+
+import (
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mec07/rununtil"
+	"github.com/mec07/rununtil/grpcrun"
+	"github.com/pkg/errors"
+)
+
+// fakeServer imitates *grpc.Server: Serve blocks until the server is stopped,
+// and GracefulStop blocks until the in-flight RPCs, represented by inFlight,
+// have completed or Stop has been called.
+type fakeServer struct {
+	mux           sync.Mutex
+	served        chan struct{}
+	stop          chan struct{}
+	stopOnce      sync.Once
+	inFlight      chan struct{}
+	gracefulCalls int
+	stopCalls     int
+}
+
+func newFakeServer() *fakeServer {
+	return &fakeServer{
+		served:   make(chan struct{}),
+		stop:     make(chan struct{}),
+		inFlight: make(chan struct{}),
+	}
+}
+
+func (s *fakeServer) Serve(lis net.Listener) error {
+	close(s.served)
+	<-s.stop
+	return errors.New("grpc: the server has been stopped")
+}
+
+func (s *fakeServer) GracefulStop() {
+	s.mux.Lock()
+	s.gracefulCalls++
+	s.mux.Unlock()
+	select {
+	case <-s.inFlight:
+	case <-s.stop:
+	}
+	s.stopOnce.Do(func() { close(s.stop) })
+}
+
+func (s *fakeServer) Stop() {
+	s.mux.Lock()
+	s.stopCalls++
+	s.mux.Unlock()
+	s.stopOnce.Do(func() { close(s.stop) })
+}
+
+func TestGRPCServer(t *testing.T) {
+	srv := newFakeServer()
+	close(srv.inFlight)
+
+	shutdown := grpcrun.GRPCServer(srv, nil)()
+	<-srv.served
+	shutdown()
+
+	if srv.gracefulCalls != 1 || srv.stopCalls != 0 {
+		t.Fatalf("expected only GracefulStop to have been called, got %d GracefulStop and %d Stop calls", srv.gracefulCalls, srv.stopCalls)
+	}
+}
+
+func TestGRPCServerWithTimeout_ForcesStop(t *testing.T) {
+	srv := newFakeServer()
+
+	shutdown := grpcrun.GRPCServerWithTimeout(srv, nil, 10*time.Millisecond)()
+	<-srv.served
+
+	done := make(chan struct{})
+	go func() {
+		shutdown()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("expected the server to be forcibly stopped once the timeout elapsed")
+	}
+
+	if srv.gracefulCalls != 1 || srv.stopCalls != 1 {
+		t.Fatalf("expected GracefulStop and then Stop to have been called, got %d GracefulStop and %d Stop calls", srv.gracefulCalls, srv.stopCalls)
+	}
+}
+
+func TestGRPCServer_ForcesStopAtDefaultShutdownTimeout(t *testing.T) {
+	defer func(timeout time.Duration) { rununtil.DefaultShutdownTimeout = timeout }(rununtil.DefaultShutdownTimeout)
+	rununtil.DefaultShutdownTimeout = 10 * time.Millisecond
+	srv := newFakeServer()
+
+	shutdown := grpcrun.GRPCServer(srv, nil)()
+	<-srv.served
+
+	done := make(chan struct{})
+	go func() {
+		shutdown()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("expected the server to be forcibly stopped once DefaultShutdownTimeout elapsed")
+	}
+
+	if srv.gracefulCalls != 1 || srv.stopCalls != 1 {
+		t.Fatalf("expected GracefulStop and then Stop to have been called, got %d GracefulStop and %d Stop calls", srv.gracefulCalls, srv.stopCalls)
+	}
+}
+
+// failingServer is a Server whose Serve fails straight away.
+type failingServer struct{}
+
+func (failingServer) Serve(lis net.Listener) error { return errors.New("bind: address already in use") }
+func (failingServer) GracefulStop()                {}
+func (failingServer) Stop()                        {}
+
+func TestGroupGRPCServer_ServeErrorStopsGroup(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Unexpected error when listening: %v", err)
+	}
+	defer lis.Close()
+
+	// simulated kill signals are ignored by this group, so only stopping the
+	// group itself can end the await
+	group := rununtil.NewGroup(rununtil.WithSimulateDisabled())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		group.AwaitResult([]rununtil.RunnerFunc{grpcrun.GroupGRPCServer(group, failingServer{}, lis)})
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		group.Stop()
+		t.Fatal("expected the failure to serve to have stopped the group")
+	}
+}
@@ -0,0 +1,126 @@
+package rununtil
+
+import (
+	"os/signal"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Handle controls a single await which was started by Start.
+type Handle struct {
+	stop     chan struct{}
+	stopOnce sync.Once
+	done     chan struct{}
+}
+
+// Stop initiates the graceful shutdown of the await, in the same way as a kill
+// signal would, without affecting any other awaits. It does not wait for the
+// shutdown to complete and it is safe to call more than once.
+func (h *Handle) Stop() {
+	h.stopOnce.Do(func() {
+		close(h.stop)
+	})
+}
+
+// Wait blocks until the await has returned, i.e. until all of its shutdown
+// functions have been executed, however the shutdown was triggered. It is safe
+// to call more than once, and from several go routines.
+func (h *Handle) Wait() {
+	<-h.done
+}
+
+// WaitTimeout is the same as Wait except that it gives up once timeout has
+// elapsed, returning an error if the await has still not returned, e.g. so
+// that a test can assert that the shutdown finished promptly:
+//
+//	h.Stop()
+//	if err := h.WaitTimeout(time.Second); err != nil {
+//		t.Fatal(err)
+//	}
+func (h *Handle) WaitTimeout(timeout time.Duration) error {
+	select {
+	case <-h.done:
+		return nil
+	case <-time.After(timeout):
+		return errors.Errorf("await did not return within %v", timeout)
+	}
+}
+
+// Start runs the provided RunnerFuncs and returns straight away, leaving a go
+// routine to await a kill signal, SIGINT or SIGTERM, at which point it
+// executes the graceful shutdown functions in the reverse order to which the
+// runners were registered. The await can also be stopped by SimulateKillSignal
+// or CancelAll, like any other, or on its own by calling Stop on the returned
+// Handle. This allows several independent services to be run and shutdown
+// separately, e.g. in a test harness, and replaces the pattern of running main
+// in a go routine and calling SimulateKillSignal:
+//
+//	h := rununtil.Start(NewRunner(logger))
+//	... do some stuff, e.g. send some requests to the webserver ...
+//	h.Stop()
+//	h.Wait()
+//
+// The runners have all been started by the time Start returns.
+func Start(runnerFuncs ...RunnerFunc) *Handle {
+	return defaultGroup.start(runnerFuncs)
+}
+
+// StopFunc stops the awaits on a Group and waits for their graceful shutdown
+// to complete.
+type StopFunc func()
+
+// Start runs the provided RunnerFuncs and returns straight away, leaving a go
+// routine to await a kill signal, SIGINT or SIGTERM, or a SimulateKillSignal
+// on the Group, at which point it executes the graceful shutdown functions in
+// the reverse order to which the runners were registered. The returned
+// StopFunc stops the Group, in the same way as Stop, and waits for the
+// shutdown to complete, so that, e.g., a test can run several services in
+// their own Groups and stop them independently:
+//
+//	serviceA, serviceB := rununtil.NewGroup(), rununtil.NewGroup()
+//	stopA := serviceA.Start(NewServiceA(config))
+//	stopB := serviceB.Start(NewServiceB(config))
+//	defer stopB()
+//	... test that B copes with A going away ...
+//	stopA()
+//
+// The runners have all been started by the time Start returns.
+func (g *Group) Start(runnerFuncs ...RunnerFunc) StopFunc {
+	h := g.start(runnerFuncs)
+	return func() {
+		g.Stop()
+		h.Wait()
+	}
+}
+
+// start runs the provided RunnerFuncs and returns a Handle for the await on
+// the Group.
+func (g *Group) start(runnerFuncs []RunnerFunc) *Handle {
+	h := &Handle{
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+
+	c, finish, release := g.killSignalChannels(signal.Notify, defaultSignals())
+
+	shutdowns := startRunners(runnerFuncs)
+
+	go func() {
+		defer close(h.done)
+		defer release()
+
+		select {
+		case <-c:
+			g.initiateShutdown()
+		case <-finish:
+			g.initiateShutdown()
+		case <-h.stop:
+		}
+
+		shutdownWithinDefaultTimeout(func() { shutdownInReverse(shutdowns) })
+	}()
+
+	return h
+}
@@ -0,0 +1,105 @@
+package rununtil_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mec07/rununtil"
+)
+
+// helperMakeNotifyingRunner returns a runner whose shutdown function closes the
+// shutdown channel.
+func helperMakeNotifyingRunner(shutdown chan struct{}) rununtil.RunnerFunc {
+	return rununtil.RunnerFunc(func() rununtil.ShutdownFunc {
+		return rununtil.ShutdownFunc(func() {
+			close(shutdown)
+		})
+	})
+}
+
+func TestStart_Stop(t *testing.T) {
+	shutdownA := make(chan struct{})
+	shutdownB := make(chan struct{})
+	handleA := rununtil.Start(helperMakeNotifyingRunner(shutdownA))
+	handleB := rununtil.Start(helperMakeNotifyingRunner(shutdownB))
+
+	handleA.Stop()
+	handleA.Stop()
+	select {
+	case <-shutdownA:
+	case <-time.After(time.Second):
+		t.Fatal("expected the first await to have been shutdown")
+	}
+	select {
+	case <-shutdownB:
+		t.Fatal("did not expect the second await to have been shutdown")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	handleB.Stop()
+	select {
+	case <-shutdownB:
+	case <-time.After(time.Second):
+		t.Fatal("expected the second await to have been shutdown")
+	}
+}
+
+func TestStart_CancelAll(t *testing.T) {
+	shutdown := make(chan struct{})
+	handle := rununtil.Start(helperMakeNotifyingRunner(shutdown))
+
+	rununtil.CancelAll()
+	select {
+	case <-shutdown:
+	case <-time.After(time.Second):
+		t.Fatal("expected the await to have been shutdown")
+	}
+
+	// stopping after the await has already finished is a no-op
+	handle.Stop()
+}
+
+func TestStart_Wait(t *testing.T) {
+	var hasBeenShutdown bool
+	h := rununtil.Start(helperMakeFakeRunner(&hasBeenShutdown))
+
+	h.Stop()
+	h.Stop()
+	h.Wait()
+	if !hasBeenShutdown {
+		t.Fatal("expected the shutdown function to have returned before Wait")
+	}
+	// waiting again returns straight away
+	h.Wait()
+}
+
+func TestStart_WaitAfterSimulateKillSignal(t *testing.T) {
+	var hasBeenShutdown bool
+	h := rununtil.Start(helperMakeFakeRunner(&hasBeenShutdown))
+
+	rununtil.SimulateKillSignal()
+	h.Wait()
+	if !hasBeenShutdown {
+		t.Fatal("expected the shutdown function to have returned before Wait")
+	}
+}
+
+func TestStart_WaitTimeout(t *testing.T) {
+	release := make(chan struct{})
+	blockingRunner := rununtil.RunnerFunc(func() rununtil.ShutdownFunc {
+		return func() { <-release }
+	})
+	h := rununtil.Start(blockingRunner)
+
+	if err := h.WaitTimeout(10 * time.Millisecond); err == nil {
+		t.Fatal("expected an error as the await had not been stopped")
+	}
+	h.Stop()
+	if err := h.WaitTimeout(10 * time.Millisecond); err == nil {
+		t.Fatal("expected an error as the shutdown function was still running")
+	}
+	close(release)
+	if err := h.WaitTimeout(time.Second); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
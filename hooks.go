@@ -0,0 +1,124 @@
+package rununtil
+
+import (
+	"context"
+	"os"
+	"time"
+)
+
+// Hooks holds callbacks which are invoked at key points in the lifecycle of
+// an await, e.g. to record metrics. Any of the callbacks can be left nil.
+type Hooks struct {
+	// OnRunnerStarted is called after each runner has started, with the
+	// index of the runner in the order that they were registered.
+	OnRunnerStarted func(index int)
+	// OnSignal is called with the signal which triggered the shutdown, or
+	// with nil if it was triggered by SimulateKillSignal or CancelAll.
+	OnSignal func(sig os.Signal)
+	// OnShutdownReason is called with what triggered the shutdown, which
+	// distinguishes a real kill signal from a simulated one.
+	OnShutdownReason func(reason ShutdownReason)
+	// OnShutdownStart is called just before the first shutdown function. The
+	// context it is given carries the deadline of the shutdown as a whole,
+	// if a timeout has been set with WithTimeout.
+	OnShutdownStart func(ctx context.Context)
+	// OnShutdownComplete is called once all of the shutdown functions have
+	// returned, with the time it took to run them.
+	OnShutdownComplete func(duration time.Duration)
+	// OnShutdownPanic is called when a shutdown function panics, with the
+	// index of its runner and the recovered value, so that a subsystem which
+	// failed to clean up doesn't go unnoticed. The panic is still propagated
+	// once all of the shutdown functions have returned.
+	OnShutdownPanic func(index int, recovered interface{})
+	// OnSlowShutdown is called, from its own go routine, if the shutdown
+	// functions are still running once the threshold set with
+	// WithSlowShutdownWarning has elapsed, with that threshold.
+	OnSlowShutdown func(threshold time.Duration)
+}
+
+func (h Hooks) runnerStarted(index int) {
+	if h.OnRunnerStarted != nil {
+		h.OnRunnerStarted(index)
+	}
+}
+
+func (h Hooks) signal(sig os.Signal) {
+	if h.OnSignal != nil {
+		h.OnSignal(sig)
+	}
+}
+
+func (h Hooks) reason(reason ShutdownReason) {
+	if h.OnShutdownReason != nil {
+		h.OnShutdownReason(reason)
+	}
+}
+
+func (h Hooks) shutdownStart(ctx context.Context) {
+	if h.OnShutdownStart != nil {
+		h.OnShutdownStart(ctx)
+	}
+}
+
+func (h Hooks) shutdownComplete(duration time.Duration) {
+	if h.OnShutdownComplete != nil {
+		h.OnShutdownComplete(duration)
+	}
+}
+
+func (h Hooks) shutdownPanic(index int, recovered interface{}) {
+	if h.OnShutdownPanic != nil {
+		h.OnShutdownPanic(index, recovered)
+	}
+}
+
+func (h Hooks) slowShutdown(threshold time.Duration) {
+	if h.OnSlowShutdown != nil {
+		h.OnSlowShutdown(threshold)
+	}
+}
+
+// then returns Hooks which invoke each of the callbacks in h followed by the
+// matching one in next, so that hooks set by the Options of a Group and by
+// the call site are all invoked.
+func (h Hooks) then(next Hooks) Hooks {
+	return Hooks{
+		OnRunnerStarted: func(index int) {
+			h.runnerStarted(index)
+			next.runnerStarted(index)
+		},
+		OnSignal: func(sig os.Signal) {
+			h.signal(sig)
+			next.signal(sig)
+		},
+		OnShutdownReason: func(reason ShutdownReason) {
+			h.reason(reason)
+			next.reason(reason)
+		},
+		OnShutdownStart: func(ctx context.Context) {
+			h.shutdownStart(ctx)
+			next.shutdownStart(ctx)
+		},
+		OnShutdownComplete: func(duration time.Duration) {
+			h.shutdownComplete(duration)
+			next.shutdownComplete(duration)
+		},
+		OnShutdownPanic: func(index int, recovered interface{}) {
+			h.shutdownPanic(index, recovered)
+			next.shutdownPanic(index, recovered)
+		},
+		OnSlowShutdown: func(threshold time.Duration) {
+			h.slowShutdown(threshold)
+			next.slowShutdown(threshold)
+		},
+	}
+}
+
+// AwaitKillSignalsWithHooks runs the provided RunnerFuncs until the specified
+// signals have been recieved, at which point it executes the graceful shutdown
+// functions in the reverse order to which the runners were registered. The
+// callbacks in hooks are invoked as each runner starts, when the signal is
+// received, and before and after the shutdown functions are run.
+func AwaitKillSignalsWithHooks(signals []os.Signal, hooks Hooks, runnerFuncs ...RunnerFunc) {
+	Await(runnerFuncs, WithSignals(signals...), WithHooks(hooks))
+}
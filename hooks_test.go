@@ -0,0 +1,112 @@
+package rununtil_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"reflect"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/mec07/rununtil"
+)
+
+func TestRununtilAwaitKillSignalsWithHooks(t *testing.T) {
+	var events []string
+	hooks := rununtil.Hooks{
+		OnRunnerStarted: func(index int) {
+			events = append(events, fmt.Sprintf("runner %d started", index))
+		},
+		OnSignal: func(sig os.Signal) {
+			events = append(events, fmt.Sprintf("signal %v", sig))
+		},
+		OnShutdownStart: func(ctx context.Context) {
+			events = append(events, "shutdown start")
+		},
+		OnShutdownComplete: func(duration time.Duration) {
+			if duration < 5*time.Millisecond {
+				t.Errorf("expected the shutdown duration to be at least 5ms, got: %v", duration)
+			}
+			events = append(events, "shutdown complete")
+		},
+	}
+	slowRunner := rununtil.RunnerFunc(func() rununtil.ShutdownFunc {
+		return rununtil.ShutdownFunc(func() {
+			events = append(events, "shutting down")
+			time.Sleep(5 * time.Millisecond)
+		})
+	})
+
+	rununtil.AwaitKillSignalsWithHooks(
+		[]os.Signal{syscall.SIGINT},
+		hooks,
+		slowRunner,
+		helperMakeCancellingRunner(),
+	)
+
+	expected := []string{
+		"runner 0 started",
+		"runner 1 started",
+		"signal <nil>",
+		"shutdown start",
+		"shutting down",
+		"shutdown complete",
+	}
+	if !reflect.DeepEqual(events, expected) {
+		t.Fatalf("expected events %q, got: %q", expected, events)
+	}
+}
+
+func TestRununtilAwaitKillSignalsWithHooks_NoHooks(t *testing.T) {
+	var hasBeenShutdown bool
+	rununtil.AwaitKillSignalsWithHooks(
+		[]os.Signal{syscall.SIGINT},
+		rununtil.Hooks{},
+		helperMakeFakeRunner(&hasBeenShutdown),
+		helperMakeCancellingRunner(),
+	)
+
+	if !hasBeenShutdown {
+		t.Fatal("expected the shutdown function to have been called")
+	}
+}
+
+func TestRununtilAwaitKillSignalsWithHooks_ShutdownPanic(t *testing.T) {
+	var panics []string
+	hooks := rununtil.Hooks{
+		OnShutdownPanic: func(index int, recovered interface{}) {
+			panics = append(panics, fmt.Sprintf("runner %d: %v", index, recovered))
+		},
+	}
+	panickingRunner := func(msg string) rununtil.RunnerFunc {
+		return rununtil.RunnerFunc(func() rununtil.ShutdownFunc {
+			return rununtil.ShutdownFunc(func() {
+				panic(msg)
+			})
+		})
+	}
+	var hasBeenShutdown bool
+
+	defer func() {
+		if recovered := recover(); recovered != "second" {
+			t.Fatalf("expected the first panic to be propagated, got: %v", recovered)
+		}
+		expected := []string{"runner 2: second", "runner 0: first"}
+		if !reflect.DeepEqual(panics, expected) {
+			t.Fatalf("expected panics %q, got: %q", expected, panics)
+		}
+		if !hasBeenShutdown {
+			t.Fatal("expected the other shutdown function to have been called")
+		}
+	}()
+
+	rununtil.AwaitKillSignalsWithHooks(
+		[]os.Signal{syscall.SIGINT},
+		hooks,
+		panickingRunner("first"),
+		helperMakeFakeRunner(&hasBeenShutdown),
+		panickingRunner("second"),
+		helperMakeCancellingRunner(),
+	)
+}
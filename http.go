@@ -0,0 +1,70 @@
+package rununtil
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/pkg/errors"
+)
+
+// HTTPServer returns a RunnerFunc which runs srv.ListenAndServe in a go
+// routine, and whose ShutdownFunc gracefully shuts the server down with
+// srv.Shutdown, waiting for the in-flight requests to complete:
+//
+//	rununtil.AwaitKillSignal(rununtil.HTTPServer(&http.Server{Addr: ":8080", Handler: r}))
+//
+// srv.Shutdown is bounded by the default Group's ShutdownContext, i.e. by the
+// deadline of the await's shutdown or else by DefaultShutdownTimeout. If it
+// runs out, the remaining connections are closed with srv.Close. If the server
+// fails, e.g. because its address is already in use, the error is reported on
+// stderr and the default Group is stopped, rather than leaving the process
+// running without it.
+func HTTPServer(srv *http.Server) RunnerFunc {
+	return defaultGroup.HTTPServer(srv)
+}
+
+// HTTPServer is the same as the package level HTTPServer except that the
+// shutdown is bounded by g's ShutdownContext, and a server failure stops g.
+func (g *Group) HTTPServer(srv *http.Server) RunnerFunc {
+	return g.serveHTTP(srv, srv.ListenAndServe)
+}
+
+// HTTPServerTLS is the same as HTTPServer except that it runs
+// srv.ListenAndServeTLS with the provided certificate and key files. If the
+// certificates are instead provided by srv.TLSConfig, e.g. by its
+// GetCertificate callback, pass empty strings for certFile and keyFile.
+func HTTPServerTLS(srv *http.Server, certFile, keyFile string) RunnerFunc {
+	return defaultGroup.HTTPServerTLS(srv, certFile, keyFile)
+}
+
+// HTTPServerTLS is the same as the package level HTTPServerTLS except that it
+// is bound to g, in the same way as Group.HTTPServer.
+func (g *Group) HTTPServerTLS(srv *http.Server, certFile, keyFile string) RunnerFunc {
+	return g.serveHTTP(srv, func() error {
+		return srv.ListenAndServeTLS(certFile, keyFile)
+	})
+}
+
+// serveHTTP returns a RunnerFunc which runs serve in a go routine, and whose
+// ShutdownFunc gracefully shuts srv down within g's ShutdownContext.
+func (g *Group) serveHTTP(srv *http.Server, serve func() error) RunnerFunc {
+	return func() ShutdownFunc {
+		go func() {
+			if err := serve(); err != nil && err != http.ErrServerClosed {
+				fmt.Fprintf(os.Stderr, "ERROR: %+v\n", errors.Wrapf(err, "serving HTTP on %q, initiating shutdown", srv.Addr))
+				g.Stop()
+			}
+		}()
+
+		return func() {
+			ctx, cancel := g.ShutdownContext()
+			defer cancel()
+
+			if err := srv.Shutdown(ctx); err != nil {
+				fmt.Fprintf(os.Stderr, "ERROR: %+v\n", errors.Wrapf(err, "shutting down HTTP server on %q, closing it", srv.Addr))
+				srv.Close()
+			}
+		}
+	}
+}
@@ -0,0 +1,189 @@
+package rununtil_test
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/mec07/rununtil"
+)
+
+// helperFreeAddr returns an address on localhost which nothing is listening
+// on.
+func helperFreeAddr(t *testing.T) string {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Unexpected error when finding a free port: %v", err)
+	}
+	defer l.Close()
+	return l.Addr().String()
+}
+
+func TestRununtilHTTPServer(t *testing.T) {
+	addr := helperFreeAddr(t)
+	srv := &http.Server{
+		Addr: addr,
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusTeapot)
+		}),
+	}
+
+	shutdown := rununtil.HTTPServer(srv)()
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		resp, err := http.Get("http://" + addr)
+		if err == nil {
+			resp.Body.Close()
+			if resp.StatusCode != http.StatusTeapot {
+				t.Fatalf("expected status %d, got: %d", http.StatusTeapot, resp.StatusCode)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected the server to be listening, got: %v", err)
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	shutdown()
+
+	if _, err := http.Get("http://" + addr); err == nil {
+		t.Fatal("expected the server to have been shutdown")
+	}
+}
+
+func TestRununtilHTTPServer_AddressInUse(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Unexpected error when listening: %v", err)
+	}
+	defer l.Close()
+	srv := &http.Server{Addr: l.Addr().String()}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		rununtil.AwaitKillSignal(rununtil.HTTPServer(srv))
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		rununtil.CancelAll()
+		t.Fatal("expected the failure to listen to have initiated a shutdown")
+	}
+}
+
+func TestRununtilHTTPServerTLS(t *testing.T) {
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	}))
+	ts.StartTLS()
+	// reuse the test certificate with our own server, on a different port
+	tlsConfig := ts.TLS.Clone()
+	client := ts.Client()
+	ts.Close()
+
+	addr := helperFreeAddr(t)
+	srv := &http.Server{Addr: addr, Handler: ts.Config.Handler, TLSConfig: tlsConfig}
+
+	shutdown := rununtil.HTTPServerTLS(srv, "", "")()
+	defer shutdown()
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		resp, err := client.Get("https://" + addr)
+		if err == nil {
+			resp.Body.Close()
+			if resp.StatusCode != http.StatusTeapot {
+				t.Fatalf("expected status %d, got: %d", http.StatusTeapot, resp.StatusCode)
+			}
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected the server to be serving TLS, got: %v", err)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestGroupHTTPServer_AddressInUse(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Unexpected error when listening: %v", err)
+	}
+	defer l.Close()
+	srv := &http.Server{Addr: l.Addr().String()}
+
+	// simulated kill signals are ignored by this group, so only stopping the
+	// group itself can end the await
+	group := rununtil.NewGroup(rununtil.WithSimulateDisabled())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		group.AwaitResult([]rununtil.RunnerFunc{group.HTTPServer(srv)})
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		group.Stop()
+		t.Fatal("expected the failure to listen to have stopped the group")
+	}
+}
+
+func TestRununtilHTTPServer_ShutdownDeadline(t *testing.T) {
+	defer func(timeout time.Duration) { rununtil.DefaultShutdownTimeout = timeout }(rununtil.DefaultShutdownTimeout)
+	rununtil.DefaultShutdownTimeout = 50 * time.Millisecond
+
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	defer close(release)
+	addr := helperFreeAddr(t)
+	srv := &http.Server{
+		Addr: addr,
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			close(entered)
+			<-release
+		}),
+	}
+
+	shutdown := rununtil.HTTPServer(srv)()
+
+	go func() {
+		// retry until the server is listening; the request which reaches the
+		// handler is held there until the test ends
+		for {
+			resp, err := http.Get("http://" + addr)
+			if err == nil {
+				resp.Body.Close()
+			}
+			select {
+			case <-entered:
+				return
+			case <-time.After(time.Millisecond):
+			}
+		}
+	}()
+	select {
+	case <-entered:
+	case <-time.After(time.Second):
+		t.Fatal("expected the request to have reached the handler")
+	}
+
+	shutdownDone := make(chan struct{})
+	go func() {
+		defer close(shutdownDone)
+		shutdown()
+	}()
+
+	select {
+	case <-shutdownDone:
+	case <-time.After(time.Second):
+		t.Fatal("expected the shutdown to be bounded by DefaultShutdownTimeout")
+	}
+}
@@ -0,0 +1,75 @@
+package rununtil
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+)
+
+// Job returns a RunnerFunc for a finite job, e.g. a batch import, which should
+// run to completion and then let the process exit, while still respecting the
+// kill signals. The job is run in a go routine and, once it returns, the
+// default Group is stopped, in the same way as Stop, so that the await
+// returns with ReasonJobDone. If a kill signal arrives first, the ShutdownFunc cancels the job's
+// context and waits for it to return. Use it with AwaitResult to retrieve the
+// job's error:
+//
+//	result := rununtil.AwaitResult([]rununtil.RunnerFunc{rununtil.Job(runImport)})
+//	if result.JobErr != nil {
+//		os.Exit(1)
+//	}
+//
+// A job returning context.Canceled after its context has been cancelled is not
+// treated as an error.
+//
+// To run a job in an await on another Group, use the Job method of that Group.
+func Job(fn func(ctx context.Context) error) RunnerFunc {
+	return defaultGroup.Job(fn)
+}
+
+// Job is the same as the package level Job except that, once the job returns,
+// it stops the Group and its error is reported by the AwaitResult of the
+// Group:
+//
+//	result := group.AwaitResult([]rununtil.RunnerFunc{group.Job(runImport)})
+func (g *Group) Job(fn func(ctx context.Context) error) RunnerFunc {
+	return func() ShutdownFunc {
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan struct{})
+
+		go func() {
+			defer close(done)
+			err := fn(ctx)
+			if err != nil && !(ctx.Err() != nil && errors.Cause(err) == context.Canceled) {
+				g.recordJobErr(err)
+			}
+			if ctx.Err() == nil {
+				g.stop(ReasonJobDone)
+			}
+		}()
+
+		return func() {
+			cancel()
+			<-done
+		}
+	}
+}
+
+// recordJobErr retains the first error returned by a Job.
+func (g *Group) recordJobErr(err error) {
+	g.jobMux.Lock()
+	defer g.jobMux.Unlock()
+	if g.jobErr == nil {
+		g.jobErr = err
+	}
+}
+
+// takeJobErr returns the first error returned by a Job since it was last
+// called.
+func (g *Group) takeJobErr() error {
+	g.jobMux.Lock()
+	defer g.jobMux.Unlock()
+	err := g.jobErr
+	g.jobErr = nil
+	return err
+}
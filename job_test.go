@@ -0,0 +1,86 @@
+package rununtil_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mec07/rununtil"
+	"github.com/pkg/errors"
+)
+
+func TestRununtilJob(t *testing.T) {
+	errImport := errors.New("import failed")
+	var hasBeenShutdown bool
+
+	result := rununtil.AwaitResult([]rununtil.RunnerFunc{
+		helperMakeFakeRunner(&hasBeenShutdown),
+		rununtil.Job(func(ctx context.Context) error {
+			return errImport
+		}),
+	})
+
+	if result.JobErr != errImport {
+		t.Fatalf("expected the job error to be returned, got: %v", result.JobErr)
+	}
+	if !hasBeenShutdown {
+		t.Fatal("expected the other runners to have been shutdown once the job finished")
+	}
+}
+
+func TestRununtilJob_Cancelled(t *testing.T) {
+	var cancelled bool
+	job := rununtil.Job(func(ctx context.Context) error {
+		select {
+		case <-ctx.Done():
+			cancelled = true
+			return ctx.Err()
+		case <-time.After(time.Second):
+			return errors.New("expected the job to have been cancelled")
+		}
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	result := rununtil.AwaitResult([]rununtil.RunnerFunc{job}, rununtil.WithContext(ctx))
+
+	if result.Reason != rununtil.ReasonContext {
+		t.Fatalf("expected the shutdown to have been triggered by the context, got: %v", result.Reason)
+	}
+	if !cancelled {
+		t.Fatal("expected the job's context to have been cancelled")
+	}
+	if result.JobErr != nil {
+		t.Fatalf("did not expect cancellation to be treated as an error, got: %v", result.JobErr)
+	}
+}
+
+func TestGroupJob(t *testing.T) {
+	group := rununtil.NewGroup(rununtil.WithSimulateDisabled())
+	errImport := errors.New("import failed")
+	var hasBeenShutdown bool
+	onDefaultGroup := rununtil.Start(helperMakeFakeRunner(&hasBeenShutdown))
+	defer onDefaultGroup.Wait()
+	defer onDefaultGroup.Stop()
+	done := make(chan rununtil.Result)
+	go func() {
+		done <- group.AwaitResult([]rununtil.RunnerFunc{
+			group.Job(func(ctx context.Context) error {
+				return errImport
+			}),
+		})
+	}()
+
+	select {
+	case result := <-done:
+		if errors.Cause(result.JobErr) != errImport {
+			t.Fatalf("expected the error from the job, got: %v", result.JobErr)
+		}
+	case <-time.After(time.Second):
+		group.Stop()
+		t.Fatal("expected the job finishing to have stopped the await on its group")
+	}
+	if onDefaultGroup.WaitTimeout(10*time.Millisecond) == nil || hasBeenShutdown {
+		t.Fatal("did not expect the job to have stopped the await on the default group")
+	}
+}
@@ -0,0 +1,36 @@
+package rununtil
+
+import (
+	"fmt"
+	"os"
+)
+
+// Logger is the minimal logging interface which rununtil uses to report
+// lifecycle events, e.g. which signal was received and when shutdown has
+// completed. It is satisfied by many logging libraries and can easily be
+// adapted to others.
+type Logger interface {
+	Infof(format string, args ...interface{})
+}
+
+// noopLogger is a Logger which discards everything, so that rununtil is
+// silent unless a Logger has been provided.
+type noopLogger struct{}
+
+func (noopLogger) Infof(format string, args ...interface{}) {}
+
+// stderrLogger is a Logger which writes to stderr.
+type stderrLogger struct{}
+
+func (stderrLogger) Infof(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, format+"\n", args...)
+}
+
+// AwaitKillSignalsWithLogger runs the provided RunnerFuncs until the specified
+// signals have been recieved, at which point it executes the graceful shutdown
+// functions in the reverse order to which the runners were registered. The key
+// lifecycle events are logged to the provided Logger. If the logger is nil
+// nothing is logged.
+func AwaitKillSignalsWithLogger(signals []os.Signal, logger Logger, runnerFuncs ...RunnerFunc) {
+	Await(runnerFuncs, WithSignals(signals...), WithLogger(logger))
+}
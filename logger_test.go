@@ -0,0 +1,62 @@
+package rununtil_test
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"syscall"
+	"testing"
+
+	"github.com/mec07/rununtil"
+)
+
+type recordingLogger struct {
+	messages []string
+}
+
+func (l *recordingLogger) Infof(format string, args ...interface{}) {
+	l.messages = append(l.messages, fmt.Sprintf(format, args...))
+}
+
+func TestRununtilAwaitKillSignalsWithLogger(t *testing.T) {
+	var logger recordingLogger
+	shutdownRunner := rununtil.RunnerFunc(func() rununtil.ShutdownFunc {
+		return rununtil.ShutdownFunc(func() {
+			logger.Infof("shutting down runner 0")
+		})
+	})
+
+	rununtil.AwaitKillSignalsWithLogger(
+		[]os.Signal{syscall.SIGINT},
+		&logger,
+		shutdownRunner,
+		helperMakeCancellingRunner(),
+	)
+
+	expected := []string{
+		"starting runner 0",
+		"starting runner 1",
+		"all runners started",
+		"received simulated kill signal",
+		"beginning shutdown",
+		"shutting down runner 0",
+		"shutdown complete",
+	}
+	if !reflect.DeepEqual(logger.messages, expected) {
+		t.Fatalf("expected messages %q, got: %q", expected, logger.messages)
+	}
+}
+
+func TestRununtilAwaitKillSignalsWithLogger_NilLogger(t *testing.T) {
+	var hasBeenShutdown bool
+	rununtil.AwaitKillSignalsWithLogger(
+		[]os.Signal{syscall.SIGINT},
+		nil,
+		helperMakeFakeRunner(&hasBeenShutdown),
+		helperMakeCancellingRunner(),
+	)
+
+	if !hasBeenShutdown {
+		t.Fatal("expected the shutdown function to have been called")
+	}
+}
@@ -0,0 +1,26 @@
+package rununtil
+
+import (
+	"os"
+)
+
+// NotifyFunc is a function with the same signature as signal.Notify. It
+// registers the channel to receive the specified signals.
+type NotifyFunc func(c chan<- os.Signal, sig ...os.Signal)
+
+// AwaitKillSignalsWithNotifier runs the provided RunnerFuncs until the
+// specified signals have been recieved, at which point it executes the
+// graceful shutdown functions in the reverse order to which the runners were
+// registered. It uses the provided NotifyFunc to listen for the signals
+// instead of signal.Notify, which allows tests to feed synthetic signals into
+// the channel rather than sending real signals to the whole process.
+func AwaitKillSignalsWithNotifier(notify NotifyFunc, signals []os.Signal, runnerFuncs ...RunnerFunc) {
+	wait, release := defaultGroup.listenForKillSignalWithNotifier(notify, signals)
+	defer release()
+
+	shutdowns := startRunners(runnerFuncs)
+
+	wait()
+
+	shutdownWithinDefaultTimeout(func() { shutdownInReverse(shutdowns) })
+}
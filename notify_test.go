@@ -0,0 +1,46 @@
+package rununtil_test
+
+import (
+	"os"
+	"reflect"
+	"syscall"
+	"testing"
+
+	"github.com/mec07/rununtil"
+)
+
+// fakeNotifier records the channel and signals it was given so that tests can
+// deliver synthetic signals.
+type fakeNotifier struct {
+	c       chan<- os.Signal
+	signals []os.Signal
+}
+
+func (n *fakeNotifier) notify(c chan<- os.Signal, sig ...os.Signal) {
+	n.c = c
+	n.signals = sig
+}
+
+func TestRununtilAwaitKillSignalsWithNotifier(t *testing.T) {
+	var notifier fakeNotifier
+	var hasBeenShutdown bool
+	signalSender := rununtil.RunnerFunc(func() rununtil.ShutdownFunc {
+		notifier.c <- syscall.SIGTERM
+		return rununtil.ShutdownFunc(func() {})
+	})
+
+	signals := []os.Signal{syscall.SIGINT, syscall.SIGTERM}
+	rununtil.AwaitKillSignalsWithNotifier(
+		notifier.notify,
+		signals,
+		helperMakeFakeRunner(&hasBeenShutdown),
+		signalSender,
+	)
+
+	if !reflect.DeepEqual(notifier.signals, signals) {
+		t.Fatalf("expected the notifier to be given %v, got: %v", signals, notifier.signals)
+	}
+	if !hasBeenShutdown {
+		t.Fatal("expected the shutdown function to have been called")
+	}
+}
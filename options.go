@@ -0,0 +1,635 @@
+package rununtil
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Option configures the behaviour of Await.
+type Option func(*config)
+
+// config holds the behaviour of an await, as configured by Options.
+type config struct {
+	ctx             context.Context
+	signals         []os.Signal
+	timeout         time.Duration
+	logger          Logger
+	hooks           Hooks
+	parallel        bool
+	gap             time.Duration
+	preShutdown     func(ctx context.Context)
+	preShutdownWait func(ctx context.Context) error
+	reraise         bool
+	forceQuit       bool
+	finalizer       func()
+	requireRunners  bool
+	timeoutEnv      string
+	signalChannel   <-chan os.Signal
+	signalGuard     func(os.Signal) bool
+	stdinEOF        bool
+	slowShutdown    time.Duration
+}
+
+// DefaultShutdownTimeout is the timeout, see WithTimeout, of every await which
+// has not been given one: those built on Await, e.g. AwaitKillSignal, use it
+// as their default WithTimeout, and the others, e.g. AwaitKillSignalsInOrder
+// and Start, force the process to exit with status code 1 if their shutdown
+// functions have not all returned once it has elapsed. The awaits which take a
+// timeout of their own, e.g. AwaitKillSignalsWithWatchdog, use that instead.
+// Its zero value means that there is no timeout. It should only be set during
+// init, e.g. in main before any awaits have started: changing it while awaits
+// are running is undefined behaviour.
+//
+//	rununtil.DefaultShutdownTimeout = 30 * time.Second
+var DefaultShutdownTimeout time.Duration
+
+func newConfig(opts []Option) config {
+	cfg := config{
+		signals: defaultSignals(),
+		timeout: DefaultShutdownTimeout,
+		logger:  noopLogger{},
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	if cfg.timeoutEnv != "" {
+		// resolved once all of the options have been applied, so that the
+		// logger has been set whatever the order of the options
+		cfg.timeout = timeoutFromEnv(cfg.timeoutEnv, cfg.timeout, cfg.logger)
+	}
+	return cfg
+}
+
+// WithSignals sets the signals which trigger the shutdown. By default these
+// are SIGINT and SIGTERM, or os.Interrupt on Windows.
+func WithSignals(signals ...os.Signal) Option {
+	return func(cfg *config) {
+		cfg.signals = signals
+	}
+}
+
+// WithSignalGuard consults guard whenever one of the signals is received: if it
+// returns false the signal is ignored, and logged, and the await carries on
+// waiting. This allows, e.g., a SIGTERM to be ignored during a critical
+// section and honoured afterwards. The guard is called from the go routine
+// which is waiting for the signals, so it must be cheap and safe to call
+// concurrently with the rest of the program. It is not consulted for
+// SimulateKillSignal or a cancelled context.
+func WithSignalGuard(guard func(os.Signal) bool) Option {
+	return func(cfg *config) {
+		cfg.signalGuard = guard
+	}
+}
+
+// WithSignalChannel waits for the signals on sigCh, which is owned by the
+// caller, instead of calling signal.Notify, e.g. when one central go routine
+// owns the signal handling for the whole process and fans the signals out to
+// the awaits. The signals set with WithSignals are ignored.
+func WithSignalChannel(sigCh <-chan os.Signal) Option {
+	return func(cfg *config) {
+		cfg.signalChannel = sigCh
+	}
+}
+
+// WithContext also initiates the shutdown when ctx is cancelled, in the same
+// way as a kill signal would.
+func WithContext(ctx context.Context) Option {
+	return func(cfg *config) {
+		cfg.ctx = ctx
+	}
+}
+
+// WithTimeout forces the process to exit with status code 1 if the shutdown
+// functions have not all returned once timeout has elapsed. Any shutdown
+// functions that are still running at that point are abandoned. A timeout of
+// zero means that there is no timeout, which is the default unless
+// DefaultShutdownTimeout has been set.
+func WithTimeout(timeout time.Duration) Option {
+	return func(cfg *config) {
+		cfg.timeout = timeout
+	}
+}
+
+// WithTimeoutFromEnv is the same as WithTimeout except that the timeout is
+// read from the environment variable key, falling back to fallback, see
+// TimeoutFromEnv. A value which is not a valid duration is reported to the
+// Logger set with WithLogger.
+func WithTimeoutFromEnv(key string, fallback time.Duration) Option {
+	return func(cfg *config) {
+		cfg.timeout = fallback
+		cfg.timeoutEnv = key
+	}
+}
+
+// WithLogger logs the key lifecycle events to the provided Logger. If the
+// logger is nil nothing is logged.
+func WithLogger(logger Logger) Option {
+	return func(cfg *config) {
+		if logger == nil {
+			logger = noopLogger{}
+		}
+		cfg.logger = logger
+	}
+}
+
+// WithHooks invokes the callbacks in hooks as each runner starts, when the
+// signal is received, and before and after the shutdown functions are run.
+// It can be given more than once, e.g. both in the Options of a Group and at
+// the call site, in which case all of the hooks are invoked, in the order
+// they were given.
+func WithHooks(hooks Hooks) Option {
+	return func(cfg *config) {
+		cfg.hooks = cfg.hooks.then(hooks)
+	}
+}
+
+// WithParallelShutdown executes all of the shutdown functions concurrently,
+// rather than in the reverse order to which the runners were registered. It
+// can't be combined with WithGap, which is ignored.
+func WithParallelShutdown() Option {
+	return func(cfg *config) {
+		cfg.parallel = true
+	}
+}
+
+// WithGap pauses for gap between consecutive shutdown functions. It has no
+// effect when combined with WithParallelShutdown.
+func WithGap(gap time.Duration) Option {
+	return func(cfg *config) {
+		cfg.gap = gap
+	}
+}
+
+// WithPreShutdown calls preShutdown exactly once, as soon as the signal has
+// been received and before the first shutdown function. The context it is
+// given carries the deadline of the shutdown as a whole, if a timeout has
+// been set with WithTimeout, so that the hook can respect the budget too.
+// It can be given more than once, in which case the functions are called in
+// the order they were given, so that, e.g., AwaitKillSignalsStop does not
+// replace one set in the Options of the Group.
+func WithPreShutdown(preShutdown func(ctx context.Context)) Option {
+	return func(cfg *config) {
+		previous := cfg.preShutdown
+		if previous == nil {
+			cfg.preShutdown = preShutdown
+			return
+		}
+		cfg.preShutdown = func(ctx context.Context) {
+			previous(ctx)
+			preShutdown(ctx)
+		}
+	}
+}
+
+// WithPreShutdownWait calls wait once the signal has been received, after the
+// function set with WithPreShutdown, and blocks until it returns before
+// executing the first shutdown function. This is where, e.g., deregistering
+// from service discovery belongs, so that peers have acknowledged that the
+// process is leaving before it stops serving. The context it is given carries
+// the deadline of the shutdown as a whole, if a timeout has been set with
+// WithTimeout, and the await stops waiting once the deadline has passed even
+// if wait has not returned. The error returned by wait, or the error of the
+// context if the deadline passed, is reported in the PreShutdownErr of the
+// Result. The shutdown functions are executed either way, but the time spent
+// waiting counts towards the timeout. It can be given more than once, in which
+// case the functions are called one after the other in the order they were
+// given, each of them even if an earlier one failed, and the first error is
+// the one reported.
+func WithPreShutdownWait(wait func(ctx context.Context) error) Option {
+	return func(cfg *config) {
+		previous := cfg.preShutdownWait
+		if previous == nil {
+			cfg.preShutdownWait = wait
+			return
+		}
+		cfg.preShutdownWait = func(ctx context.Context) error {
+			err := previous(ctx)
+			if waitErr := wait(ctx); err == nil {
+				err = waitErr
+			}
+			return err
+		}
+	}
+}
+
+// WithReraise re-raises the signal which triggered the shutdown once the
+// shutdown functions have returned, so that the parent process sees that it
+// was killed by that signal. See AwaitKillSignalsReraise.
+func WithReraise() Option {
+	return func(cfg *config) {
+		cfg.reraise = true
+	}
+}
+
+// ErrNoRunners is the value that an await panics with if it is given no
+// runners when WithRequireRunners has been set.
+var ErrNoRunners = errors.New("awaiting with no runners registered")
+
+// WithRequireRunners panics with ErrNoRunners if the await is given no
+// runners, which is almost always a bug, e.g. a slice of runners which was
+// accidentally left empty. Without it a warning is logged instead.
+func WithRequireRunners() Option {
+	return func(cfg *config) {
+		cfg.requireRunners = true
+	}
+}
+
+// WithSlowShutdownWarning logs a warning, and calls the OnSlowShutdown hook,
+// if the shutdown functions have not all returned once threshold has elapsed,
+// but unlike WithTimeout it carries on waiting for them. This helps to notice
+// a sluggish shutdown before tightening the timeout.
+func WithSlowShutdownWarning(threshold time.Duration) Option {
+	return func(cfg *config) {
+		cfg.slowShutdown = threshold
+	}
+}
+
+// WithFinalizer calls finalizer exactly once at the very end of the shutdown,
+// strictly after every shutdown function has returned, even if some of them
+// panicked, e.g. to flush a tracing span processor or a buffered log writer.
+// It is the dual of WithPreShutdown. A panic in finalizer is recovered and
+// reported on stderr. It is not called if the process is forced to exit
+// because the shutdown did not complete in time. It can be given more than
+// once, in which case the functions are called in the order they were given,
+// each of them even if an earlier one panicked, so that a finalizer passed to
+// the await does not replace one set in the Options of the Group.
+func WithFinalizer(finalizer func()) Option {
+	return func(cfg *config) {
+		previous := cfg.finalizer
+		if previous == nil {
+			cfg.finalizer = finalizer
+			return
+		}
+		cfg.finalizer = func() {
+			defer finalizer()
+			previous()
+		}
+	}
+}
+
+// WithForceQuitOnSecondSignal forces the process to exit with status code 1 if
+// another kill signal is received while the shutdown functions are still
+// running, e.g. when an impatient operator hits Ctrl-C a second time. Any
+// shutdown functions that are still running at that point are abandoned.
+func WithForceQuitOnSecondSignal() Option {
+	return func(cfg *config) {
+		cfg.forceQuit = true
+	}
+}
+
+// Await runs the provided RunnerFuncs until it receives a kill signal, or
+// SimulateKillSignal or CancelAll is called, at which point it executes the
+// graceful shutdown functions in the reverse order to which the runners were
+// registered. Its behaviour can be configured with Options, for example:
+//
+//	rununtil.Await(
+//		[]rununtil.RunnerFunc{NewRunner(logger)},
+//		rununtil.WithSignals(syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP),
+//		rununtil.WithTimeout(30*time.Second),
+//		rununtil.WithLogger(logger),
+//	)
+func Await(runnerFuncs []RunnerFunc, opts ...Option) {
+	defaultGroup.Await(runnerFuncs, opts...)
+}
+
+// Await is the same as the package level Await except that the await runs on
+// the Group, so it is stopped by SimulateKillSignal on the Group rather than
+// on the default Group, and the Group's Options are applied.
+func (g *Group) Await(runnerFuncs []RunnerFunc, opts ...Option) {
+	g.await(opts, runnerFuncs)
+}
+
+// AwaitReason is the same as Await except that it returns what triggered the
+// shutdown, e.g. to exit with a failure if the process stopped itself, but
+// not in a test which simulated the kill signal:
+//
+//	switch rununtil.AwaitReason(runners) {
+//	case rununtil.ReasonSignal, rununtil.ReasonJobDone:
+//		os.Exit(0)
+//	case rununtil.ReasonStopped:
+//		os.Exit(1)
+//	}
+func AwaitReason(runnerFuncs []RunnerFunc, opts ...Option) ShutdownReason {
+	return defaultGroup.await(opts, runnerFuncs).Reason
+}
+
+// AwaitResult is the same as Await except that it returns the outcome of the
+// shutdown as a Result, including the error returned by a Job:
+//
+//	result := rununtil.AwaitResult([]rununtil.RunnerFunc{rununtil.Job(runImport)})
+//	if result.JobErr != nil {
+//		log.Fatal().Err(result.JobErr).Msg("import failed")
+//	}
+func AwaitResult(runnerFuncs []RunnerFunc, opts ...Option) Result {
+	return defaultGroup.await(opts, runnerFuncs)
+}
+
+// AwaitResult is the same as Await except that it returns the outcome of the
+// shutdown as a Result, including the error returned by a Job of the Group.
+func (g *Group) AwaitResult(runnerFuncs []RunnerFunc, opts ...Option) Result {
+	return g.await(opts, runnerFuncs)
+}
+
+// await is the engine behind all of the awaits which take RunnerFuncs. The
+// Options of the Group, set with WithOptions, are applied before opts. It
+// returns the outcome of the shutdown: what triggered it, the signal which was
+// received, or nil if it was not triggered by a signal, and how long the
+// shutdown functions took to run.
+func (g *Group) await(opts []Option, runnerFuncs []RunnerFunc) (result Result) {
+	cfg := newConfig(append(append([]Option{}, g.options...), opts...))
+	if len(runnerFuncs) == 0 {
+		if cfg.requireRunners {
+			panic(ErrNoRunners)
+		}
+		cfg.logger.Infof("WARNING: %v", ErrNoRunners)
+	}
+
+	c, finish, release := g.signalChannels(cfg)
+	defer release()
+
+	shutdowns := startRunners(cfg.wrapRunners(runnerFuncs))
+	cfg.logger.Infof("all runners started")
+
+	stdinEOF, stopWatchingStdin := cfg.watchStdin()
+	result.Signal, result.Reason = cfg.wait(c, finish, stdinEOF, g.finishReason)
+	stopWatchingStdin()
+	g.initiateShutdown()
+	if cfg.forceQuit {
+		stop := make(chan struct{})
+		defer close(stop)
+		go cfg.forceQuitOnSignal(c, stop)
+	}
+	cfg.hooks.signal(result.Signal)
+	cfg.hooks.reason(result.Reason)
+
+	// the timeout covers the whole of the shutdown, including the hooks
+	ctx, cancel := cfg.shutdownContext()
+	defer cancel()
+	ctx = ContextWithGroup(ctx, g)
+	defer g.setShutdownContext(ctx)()
+
+	if cfg.preShutdown != nil {
+		cfg.preShutdown(ctx)
+	}
+	if cfg.preShutdownWait != nil {
+		result.PreShutdownErr = cfg.waitBeforeShutdown(ctx)
+	}
+
+	cfg.logger.Infof("beginning shutdown")
+	cfg.hooks.shutdownStart(ctx)
+	var completed bool
+	result.Duration, completed = cfg.shutdownThenFinalize(ctx, cfg.wrapShutdowns(append(shutdowns, g.takeAdded()...)))
+	result.JobErr = g.takeJobErr()
+	if !completed {
+		return result
+	}
+
+	if g.takeCrash() {
+		osExit(1)
+		return result
+	}
+
+	if cfg.reraise && result.Signal != nil {
+		reraiseOrReport(result.Signal)
+	}
+	return result
+}
+
+// wait blocks until the shutdown is triggered, and returns the signal which
+// triggered it, or nil if it was not triggered by a signal, along with what
+// triggered it, which finishReason reports if finish was closed. Signals which
+// are rejected by the signal guard are ignored.
+func (cfg config) wait(c <-chan os.Signal, finish, stdinEOF <-chan struct{}, finishReason func(<-chan struct{}) ShutdownReason) (os.Signal, ShutdownReason) {
+	var ctxDone <-chan struct{}
+	if cfg.ctx != nil {
+		ctxDone = cfg.ctx.Done()
+	}
+	for {
+		select {
+		case sig := <-c:
+			if cfg.signalGuard != nil && !cfg.signalGuard(sig) {
+				cfg.logger.Infof("ignoring signal %v, rejected by the signal guard", sig)
+				continue
+			}
+			cfg.logger.Infof("received signal %v", sig)
+			return sig, ReasonSignal
+		case <-finish:
+			reason := finishReason(finish)
+			if reason == ReasonSimulated {
+				cfg.logger.Infof("received simulated kill signal")
+			} else {
+				cfg.logger.Infof("shutdown initiated: %v", reason)
+			}
+			return nil, reason
+		case <-ctxDone:
+			cfg.logger.Infof("context cancelled")
+			return nil, ReasonContext
+		case <-stdinEOF:
+			cfg.logger.Infof("stdin closed")
+			return nil, ReasonStdinEOF
+		}
+	}
+}
+
+// signalChannels returns the channel that the signals are delivered on, the
+// channel that is closed by SimulateKillSignal and the function which must be
+// called once the await returns.
+func (g *Group) signalChannels(cfg config) (<-chan os.Signal, <-chan struct{}, func()) {
+	if cfg.signalChannel != nil {
+		finish, release := g.finishChannel()
+		return cfg.signalChannel, finish, release
+	}
+	return g.killSignalChannels(signal.Notify, cfg.signals)
+}
+
+// wrapRunners wraps each of the runners so that it is logged before it starts
+// and the OnRunnerStarted hook is called once it has started.
+func (cfg config) wrapRunners(runnerFuncs []RunnerFunc) []RunnerFunc {
+	wrapped := make([]RunnerFunc, len(runnerFuncs))
+	for idx, runner := range runnerFuncs {
+		idx, runner := idx, runner
+		wrapped[idx] = func() ShutdownFunc {
+			cfg.logger.Infof("starting runner %d", idx)
+			shutdown := noopIfNil(runner())
+			cfg.hooks.runnerStarted(idx)
+			return shutdown
+		}
+	}
+	return wrapped
+}
+
+// wrapShutdowns wraps each of the shutdown functions so that the
+// OnShutdownPanic hook is called if it panics, before the panic carries on.
+func (cfg config) wrapShutdowns(shutdowns []ShutdownFunc) []ShutdownFunc {
+	if cfg.hooks.OnShutdownPanic == nil {
+		return shutdowns
+	}
+	wrapped := make([]ShutdownFunc, len(shutdowns))
+	for idx, shutdown := range shutdowns {
+		idx, shutdown := idx, shutdown
+		wrapped[idx] = func() {
+			defer func() {
+				if recovered := recover(); recovered != nil {
+					cfg.hooks.shutdownPanic(idx, recovered)
+					panic(recovered)
+				}
+			}()
+			shutdown()
+		}
+	}
+	return wrapped
+}
+
+// forceQuitOnSignal forces the process to exit if a signal is received on c
+// before stop is closed.
+func (cfg config) forceQuitOnSignal(c <-chan os.Signal, stop <-chan struct{}) {
+	select {
+	case sig := <-c:
+		cfg.logger.Infof("received signal %v during shutdown, forcing exit", sig)
+		osExit(1)
+	case <-stop:
+	}
+}
+
+// shutdownThenFinalize executes the shutdown functions, reports that the
+// shutdown has completed and then calls the finalizer. The finalizer is also
+// called if one of the shutdown functions panicked, before the panic is
+// propagated. It returns how long the shutdown functions took, and false,
+// without calling the finalizer, if the deadline of ctx passed before they
+// had all returned.
+func (cfg config) shutdownThenFinalize(ctx context.Context, shutdowns []ShutdownFunc) (time.Duration, bool) {
+	panicked := true
+	defer func() {
+		if panicked {
+			cfg.finalize()
+		}
+	}()
+
+	start := time.Now()
+	stopWarning := cfg.warnIfSlow()
+	completed := cfg.shutdown(ctx, shutdowns)
+	duration := time.Since(start)
+	stopWarning()
+	panicked = false
+	if !completed {
+		return duration, false
+	}
+	cfg.hooks.shutdownComplete(duration)
+	cfg.logger.Infof("shutdown complete")
+	cfg.finalize()
+	return duration, true
+}
+
+// warnIfSlow starts the timer for the warning set with
+// WithSlowShutdownWarning, if there is one, and returns a function which
+// stops it. If the warning has already fired, the stop function waits for it
+// to have been logged, so that it cannot interleave with later log messages.
+func (cfg config) warnIfSlow() (stop func()) {
+	if cfg.slowShutdown <= 0 {
+		return func() {}
+	}
+	warned := make(chan struct{})
+	timer := time.AfterFunc(cfg.slowShutdown, func() {
+		defer close(warned)
+		cfg.logger.Infof("WARNING: shutdown taking longer than expected, still running after %v", cfg.slowShutdown)
+		cfg.hooks.slowShutdown(cfg.slowShutdown)
+	})
+	return func() {
+		if !timer.Stop() {
+			<-warned
+		}
+	}
+}
+
+// finalize calls the finalizer, if there is one, recovering from any panic.
+func (cfg config) finalize() {
+	if cfg.finalizer == nil {
+		return
+	}
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: recovered from panic in finalizer: %v\n", recovered)
+		}
+	}()
+	cfg.finalizer()
+}
+
+// waitBeforeShutdown calls the function set with WithPreShutdownWait, and
+// returns its error once it has returned or the deadline of ctx has passed,
+// whichever is first.
+func (cfg config) waitBeforeShutdown(ctx context.Context) error {
+	done := make(chan error, 1)
+	go func() {
+		done <- cfg.preShutdownWait(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	if err != nil {
+		err = errors.Wrap(err, "waiting before shutdown")
+		cfg.logger.Infof("ERROR: %v", err)
+	}
+	return err
+}
+
+// shutdownContext returns the context for the shutdown, whose deadline is the
+// timeout after the shutdown started, if there is one.
+func (cfg config) shutdownContext() (context.Context, context.CancelFunc) {
+	if cfg.timeout > 0 {
+		return context.WithTimeout(context.Background(), cfg.timeout)
+	}
+	return context.WithCancel(context.Background())
+}
+
+// shutdown executes the shutdown functions as configured, propagating the
+// first panic once they have all returned. It returns false if the deadline
+// of ctx passed before they had all returned.
+func (cfg config) shutdown(ctx context.Context, shutdowns []ShutdownFunc) bool {
+	var done <-chan interface{}
+	if cfg.parallel {
+		done = shutdownConcurrently(shutdowns)
+	} else if cfg.timeout > 0 {
+		done = shutdownInBackground(shutdowns, cfg.gap)
+	} else {
+		shutdownInReverseWithGap(shutdowns, cfg.gap)
+		return true
+	}
+
+	select {
+	case recovered := <-done:
+		if recovered != nil {
+			panic(recovered)
+		}
+		return true
+	case <-ctx.Done():
+		osExit(1)
+		return false
+	}
+}
+
+// shutdownInBackground executes the shutdown functions in the reverse order to
+// which they were registered in a go routine, and returns a channel which is
+// closed once they have all returned. The first panic is sent on the channel
+// before it is closed.
+func shutdownInBackground(shutdowns []ShutdownFunc, gap time.Duration) <-chan interface{} {
+	done := make(chan interface{}, 1)
+	go func() {
+		defer close(done)
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				done <- recovered
+			}
+		}()
+		shutdownInReverseWithGap(shutdowns, gap)
+	}()
+	return done
+}
@@ -0,0 +1,494 @@
+package rununtil_test
+
+import (
+	"context"
+	"os"
+	"reflect"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/mec07/rununtil"
+	"github.com/pkg/errors"
+)
+
+func TestRununtilAwait(t *testing.T) {
+	var order []int
+	logger := &recordingLogger{}
+	preShutdown := func(ctx context.Context) {
+		order = append(order, 0)
+	}
+
+	rununtil.Await(
+		[]rununtil.RunnerFunc{
+			helperMakeOrderedRunner(1, &order),
+			helperMakeOrderedRunner(2, &order),
+			helperMakeCancellingRunner(),
+		},
+		rununtil.WithSignals(syscall.SIGINT),
+		rununtil.WithLogger(logger),
+		rununtil.WithPreShutdown(preShutdown),
+	)
+
+	expectedOrder := []int{0, 2, 1}
+	if !reflect.DeepEqual(order, expectedOrder) {
+		t.Fatalf("expected order %v, got: %v", expectedOrder, order)
+	}
+	expectedMessages := []string{
+		"starting runner 0",
+		"starting runner 1",
+		"starting runner 2",
+		"all runners started",
+		"received simulated kill signal",
+		"beginning shutdown",
+		"shutdown complete",
+	}
+	if !reflect.DeepEqual(logger.messages, expectedMessages) {
+		t.Fatalf("expected messages %q, got: %q", expectedMessages, logger.messages)
+	}
+}
+
+func TestRununtilAwait_TimeoutSequential(t *testing.T) {
+	exitCode := -1
+	restore := rununtil.SetOsExit(func(code int) { exitCode = code })
+	defer restore()
+
+	blocked := make(chan struct{})
+	defer close(blocked)
+	var order []int
+	stuckRunner := rununtil.RunnerFunc(func() rununtil.ShutdownFunc {
+		return rununtil.ShutdownFunc(func() {
+			<-blocked
+		})
+	})
+
+	rununtil.Await(
+		[]rununtil.RunnerFunc{
+			helperMakeOrderedRunner(1, &order),
+			stuckRunner,
+			helperMakeCancellingRunner(),
+		},
+		rununtil.WithTimeout(10*time.Millisecond),
+	)
+
+	if exitCode != 1 {
+		t.Fatalf("expected the process to be forced to exit with code 1, got: %d", exitCode)
+	}
+	if len(order) != 0 {
+		t.Fatal("expected the shutdown functions to run sequentially, so the stuck one blocks the rest")
+	}
+}
+
+func TestGroupAwait(t *testing.T) {
+	group := rununtil.NewGroup()
+	var hasBeenShutdown bool
+	cancellingRunner := rununtil.RunnerFunc(func() rununtil.ShutdownFunc {
+		group.SimulateKillSignal()
+		return nil
+	})
+
+	group.Await([]rununtil.RunnerFunc{helperMakeFakeRunner(&hasBeenShutdown), cancellingRunner})
+
+	if !hasBeenShutdown {
+		t.Fatal("expected the shutdown function to have been called")
+	}
+}
+
+func TestRununtilAwait_ShutdownDeadline(t *testing.T) {
+	var preShutdownDeadline, shutdownStartDeadline time.Time
+	preShutdown := func(ctx context.Context) {
+		deadline, ok := ctx.Deadline()
+		if !ok {
+			t.Error("expected the pre-shutdown context to have a deadline")
+		}
+		preShutdownDeadline = deadline
+	}
+	hooks := rununtil.Hooks{
+		OnShutdownStart: func(ctx context.Context) {
+			deadline, ok := ctx.Deadline()
+			if !ok {
+				t.Error("expected the shutdown start context to have a deadline")
+			}
+			shutdownStartDeadline = deadline
+		},
+	}
+
+	start := time.Now()
+	rununtil.Await(
+		[]rununtil.RunnerFunc{helperMakeCancellingRunner()},
+		rununtil.WithTimeout(time.Minute),
+		rununtil.WithPreShutdown(preShutdown),
+		rununtil.WithHooks(hooks),
+	)
+
+	if !preShutdownDeadline.Equal(shutdownStartDeadline) {
+		t.Fatal("expected the hooks to share the same deadline")
+	}
+	if preShutdownDeadline.Before(start.Add(time.Minute)) || preShutdownDeadline.After(time.Now().Add(time.Minute)) {
+		t.Fatalf("expected the deadline to be a minute after the shutdown started, got: %v", preShutdownDeadline)
+	}
+}
+
+func TestRununtilAwait_ForceQuitOnSecondSignal(t *testing.T) {
+	exited := make(chan int, 1)
+	restore := rununtil.SetOsExit(func(code int) { exited <- code })
+	defer restore()
+
+	p, err := os.FindProcess(os.Getpid())
+	if err != nil {
+		t.Fatalf("Unexpected error when finding process: %v", err)
+	}
+	var exitCode int
+	impatientRunner := rununtil.RunnerFunc(func() rununtil.ShutdownFunc {
+		return rununtil.ShutdownFunc(func() {
+			if err := p.Signal(syscall.SIGINT); err != nil {
+				t.Errorf("Unexpected error when sending signal: %v", err)
+			}
+			select {
+			case exitCode = <-exited:
+			case <-time.After(time.Second):
+				t.Error("expected the second signal to force the process to exit")
+			}
+		})
+	})
+
+	rununtil.Await(
+		[]rununtil.RunnerFunc{impatientRunner, helperMakeCancellingRunner()},
+		rununtil.WithSignals(syscall.SIGINT),
+		rununtil.WithForceQuitOnSecondSignal(),
+	)
+
+	if exitCode != 1 {
+		t.Fatalf("expected the process to be forced to exit with code 1, got: %d", exitCode)
+	}
+}
+
+func TestRununtilAwait_ForceQuitOnSecondSignal_NoSecondSignal(t *testing.T) {
+	var exitCalled bool
+	restore := rununtil.SetOsExit(func(code int) { exitCalled = true })
+	defer restore()
+
+	var hasBeenShutdown bool
+	rununtil.Await(
+		[]rununtil.RunnerFunc{helperMakeFakeRunner(&hasBeenShutdown), helperMakeCancellingRunner()},
+		rununtil.WithSignals(syscall.SIGINT),
+		rununtil.WithForceQuitOnSecondSignal(),
+	)
+
+	if !hasBeenShutdown {
+		t.Fatal("expected the shutdown function to have been called")
+	}
+	if exitCalled {
+		t.Fatal("did not expect the process to be forced to exit")
+	}
+}
+
+func TestRununtilAwait_Finalizer(t *testing.T) {
+	var order []int
+	var finalizerCalls int
+	finalizer := func() {
+		finalizerCalls++
+		order = append(order, 0)
+	}
+
+	rununtil.Await(
+		[]rununtil.RunnerFunc{
+			helperMakeOrderedRunner(2, &order),
+			helperMakeOrderedRunner(1, &order),
+			helperMakeCancellingRunner(),
+		},
+		rununtil.WithFinalizer(finalizer),
+	)
+
+	expectedOrder := []int{1, 2, 0}
+	if !reflect.DeepEqual(order, expectedOrder) {
+		t.Fatalf("expected order %v, got: %v", expectedOrder, order)
+	}
+	if finalizerCalls != 1 {
+		t.Fatalf("expected the finalizer to be called exactly once, got: %d", finalizerCalls)
+	}
+}
+
+func TestRununtilAwait_FinalizerAfterPanic(t *testing.T) {
+	var finalized bool
+	panickingRunner := rununtil.RunnerFunc(func() rununtil.ShutdownFunc {
+		return rununtil.ShutdownFunc(func() {
+			panic("shutdown failed")
+		})
+	})
+	panickingFinalizer := func() {
+		finalized = true
+		panic("flush failed")
+	}
+
+	defer func() {
+		if recovered := recover(); recovered != "shutdown failed" {
+			t.Fatalf("expected the shutdown panic to be propagated, got: %v", recovered)
+		}
+		if !finalized {
+			t.Fatal("expected the finalizer to have been called")
+		}
+	}()
+
+	rununtil.Await(
+		[]rununtil.RunnerFunc{panickingRunner, helperMakeCancellingRunner()},
+		rununtil.WithFinalizer(panickingFinalizer),
+	)
+}
+
+func TestRununtilAwait_FinalizerGivenMoreThanOnce(t *testing.T) {
+	var order []string
+	original := rununtil.DefaultGroup()
+	defer rununtil.SetDefaultGroup(original)
+	rununtil.SetDefaultGroup(rununtil.NewGroup(rununtil.WithOptions(
+		rununtil.WithFinalizer(func() {
+			order = append(order, "group")
+			panic("flush failed")
+		}),
+	)))
+
+	rununtil.Await(
+		[]rununtil.RunnerFunc{helperMakeCancellingRunner()},
+		rununtil.WithFinalizer(func() { order = append(order, "await") }),
+	)
+
+	expectedOrder := []string{"group", "await"}
+	if !reflect.DeepEqual(order, expectedOrder) {
+		t.Fatalf("expected both finalizers to be called in order %v, got: %v", expectedOrder, order)
+	}
+}
+
+func TestRununtilAwait_NoRunners(t *testing.T) {
+	logger := &recordingLogger{}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	rununtil.Await(nil, rununtil.WithLogger(logger), rununtil.WithContext(ctx))
+
+	if len(logger.messages) == 0 || logger.messages[0] != "WARNING: awaiting with no runners registered" {
+		t.Fatalf("expected a warning to have been logged first, got: %q", logger.messages)
+	}
+}
+
+func TestRununtilAwait_RequireRunners(t *testing.T) {
+	defer func() {
+		if recovered := recover(); recovered != rununtil.ErrNoRunners {
+			t.Fatalf("expected a panic with ErrNoRunners, got: %v", recovered)
+		}
+	}()
+
+	rununtil.Await(nil, rununtil.WithRequireRunners())
+}
+
+func TestRununtilAwait_SignalGuard(t *testing.T) {
+	var allowed, consulted int32
+	guard := func(sig os.Signal) bool {
+		atomic.AddInt32(&consulted, 1)
+		return atomic.LoadInt32(&allowed) == 1
+	}
+	sigCh := make(chan os.Signal)
+	done := make(chan rununtil.Result)
+	go func() {
+		done <- rununtil.AwaitResult(
+			[]rununtil.RunnerFunc{helperMakeFakeRunner(new(bool))},
+			rununtil.WithSignalChannel(sigCh),
+			rununtil.WithSignalGuard(guard),
+		)
+	}()
+
+	// the channel is unbuffered, so the await has received the signal once
+	// the send has returned
+	sigCh <- syscall.SIGTERM
+	select {
+	case <-done:
+		t.Fatal("expected the signal rejected by the guard to have been ignored")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	atomic.StoreInt32(&allowed, 1)
+	sigCh <- syscall.SIGTERM
+	select {
+	case result := <-done:
+		if result.Signal != syscall.SIGTERM || result.Reason != rununtil.ReasonSignal {
+			t.Fatalf("expected the shutdown to have been triggered by SIGTERM, got: %+v", result)
+		}
+	case <-time.After(time.Second):
+		rununtil.CancelAll()
+		t.Fatal("expected the signal accepted by the guard to have stopped the await")
+	}
+	if calls := atomic.LoadInt32(&consulted); calls != 2 {
+		t.Fatalf("expected the guard to have been consulted twice, got: %d", calls)
+	}
+}
+
+func TestRununtilAwait_PreShutdownWait(t *testing.T) {
+	var events []string
+	failedDeregister := errors.New("failed to deregister")
+	wait := func(ctx context.Context) error {
+		events = append(events, "deregister")
+		return failedDeregister
+	}
+	shutdownRunner := rununtil.RunnerFunc(func() rununtil.ShutdownFunc {
+		return func() { events = append(events, "shutdown") }
+	})
+
+	result := rununtil.AwaitResult(
+		[]rununtil.RunnerFunc{shutdownRunner, helperMakeCancellingRunner()},
+		rununtil.WithPreShutdownWait(wait),
+	)
+
+	if !reflect.DeepEqual(events, []string{"deregister", "shutdown"}) {
+		t.Fatalf("expected the wait to happen before the shutdown, got: %v", events)
+	}
+	if errors.Cause(result.PreShutdownErr) != failedDeregister {
+		t.Fatalf("expected the error from the wait, got: %v", result.PreShutdownErr)
+	}
+}
+
+func TestRununtilAwait_PreShutdownWaitGivenMoreThanOnce(t *testing.T) {
+	var events []string
+	failedDeregister := errors.New("failed to deregister")
+	original := rununtil.DefaultGroup()
+	defer rununtil.SetDefaultGroup(original)
+	rununtil.SetDefaultGroup(rununtil.NewGroup(rununtil.WithOptions(
+		rununtil.WithPreShutdownWait(func(ctx context.Context) error {
+			events = append(events, "deregister")
+			return failedDeregister
+		}),
+	)))
+	shutdownRunner := rununtil.RunnerFunc(func() rununtil.ShutdownFunc {
+		return func() { events = append(events, "shutdown") }
+	})
+
+	result := rununtil.AwaitResult(
+		[]rununtil.RunnerFunc{shutdownRunner, helperMakeCancellingRunner()},
+		rununtil.WithPreShutdownWait(func(ctx context.Context) error {
+			events = append(events, "drain")
+			return nil
+		}),
+	)
+
+	expectedEvents := []string{"deregister", "drain", "shutdown"}
+	if !reflect.DeepEqual(events, expectedEvents) {
+		t.Fatalf("expected both waits to happen before the shutdown, in order %v, got: %v", expectedEvents, events)
+	}
+	if errors.Cause(result.PreShutdownErr) != failedDeregister {
+		t.Fatalf("expected the error from the first wait, got: %v", result.PreShutdownErr)
+	}
+}
+
+func TestRununtilAwait_PreShutdownWaitDeadline(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	ignoringWait := func(ctx context.Context) error {
+		<-release
+		return nil
+	}
+	// the wait uses up the whole of the timeout, so the shutdown is forced
+	restore := rununtil.SetOsExit(func(code int) {})
+	defer restore()
+
+	result := rununtil.AwaitResult(
+		[]rununtil.RunnerFunc{helperMakeCancellingRunner()},
+		rununtil.WithPreShutdownWait(ignoringWait),
+		rununtil.WithTimeout(20*time.Millisecond),
+	)
+
+	if errors.Cause(result.PreShutdownErr) != context.DeadlineExceeded {
+		t.Fatalf("expected the wait to have been bounded by the shutdown deadline, got: %v", result.PreShutdownErr)
+	}
+}
+
+func TestRununtilAwait_DefaultShutdownTimeout(t *testing.T) {
+	original := rununtil.DefaultShutdownTimeout
+	defer func() { rununtil.DefaultShutdownTimeout = original }()
+	rununtil.DefaultShutdownTimeout = 10 * time.Millisecond
+
+	exited := make(chan int, 2)
+	restore := rununtil.SetOsExit(func(code int) { exited <- code })
+	defer restore()
+	release := make(chan struct{})
+	defer close(release)
+	blockingRunner := rununtil.RunnerFunc(func() rununtil.ShutdownFunc {
+		return func() { <-release }
+	})
+
+	rununtil.AwaitKillSignal(blockingRunner, helperMakeCancellingRunner())
+	select {
+	case code := <-exited:
+		if code != 1 {
+			t.Fatalf("expected exit code 1, got: %d", code)
+		}
+	default:
+		t.Fatal("expected the default shutdown timeout to have forced an exit")
+	}
+
+	// an explicit timeout of zero opts out of the default
+	slowRunner := rununtil.RunnerFunc(func() rununtil.ShutdownFunc {
+		return func() { time.Sleep(20 * time.Millisecond) }
+	})
+	rununtil.Await(
+		[]rununtil.RunnerFunc{slowRunner, helperMakeCancellingRunner()},
+		rununtil.WithTimeout(0),
+	)
+	select {
+	case <-exited:
+		t.Fatal("did not expect an exit when the timeout was explicitly disabled")
+	default:
+	}
+}
+
+func TestRununtilAwait_SlowShutdownWarning(t *testing.T) {
+	logger := &recordingLogger{}
+	var warnedAfter time.Duration
+	hooks := rununtil.Hooks{OnSlowShutdown: func(threshold time.Duration) {
+		warnedAfter = threshold
+	}}
+	var hasBeenShutdown bool
+	slowRunner := rununtil.RunnerFunc(func() rununtil.ShutdownFunc {
+		return func() {
+			time.Sleep(30 * time.Millisecond)
+			hasBeenShutdown = true
+		}
+	})
+
+	rununtil.Await(
+		[]rununtil.RunnerFunc{slowRunner, helperMakeCancellingRunner()},
+		rununtil.WithSlowShutdownWarning(10*time.Millisecond),
+		rununtil.WithLogger(logger),
+		rununtil.WithHooks(hooks),
+	)
+
+	if !hasBeenShutdown {
+		t.Fatal("expected the await to have carried on waiting for the shutdown function")
+	}
+	if warnedAfter != 10*time.Millisecond {
+		t.Fatalf("expected the hook to have been called with the threshold, got: %v", warnedAfter)
+	}
+	expected := "WARNING: shutdown taking longer than expected, still running after 10ms"
+	var warned bool
+	for _, message := range logger.messages {
+		warned = warned || message == expected
+	}
+	if !warned {
+		t.Fatalf("expected the warning to have been logged, got: %q", logger.messages)
+	}
+}
+
+func TestRununtilAwait_SlowShutdownWarningNotNeeded(t *testing.T) {
+	var warned bool
+	hooks := rununtil.Hooks{OnSlowShutdown: func(threshold time.Duration) {
+		warned = true
+	}}
+
+	rununtil.Await(
+		[]rununtil.RunnerFunc{helperMakeCancellingRunner()},
+		rununtil.WithSlowShutdownWarning(time.Second),
+		rununtil.WithHooks(hooks),
+	)
+
+	if warned {
+		t.Fatal("did not expect a warning for a prompt shutdown")
+	}
+}
@@ -0,0 +1,133 @@
+package rununtil
+
+import (
+	"os"
+	"sync"
+	"time"
+)
+
+// AwaitKillSignalsParallel runs the provided RunnerFuncs until the specified
+// signals have been recieved, at which point it executes all of the graceful
+// shutdown functions concurrently and waits for them all to return. This means
+// that the total shutdown time is that of the slowest shutdown function rather
+// than the sum of them all.
+// A panic in one shutdown function does not prevent the others from
+// completing; once they have all returned the panic is propagated.
+func AwaitKillSignalsParallel(signals []os.Signal, runnerFuncs ...RunnerFunc) {
+	Await(runnerFuncs, WithSignals(signals...), WithParallelShutdown())
+}
+
+// AwaitKillSignalsParallelTimeout runs the provided RunnerFuncs until the
+// specified signals have been recieved, at which point it executes all of the
+// graceful shutdown functions concurrently and waits up to timeout for them
+// all to return. It returns the indices, in the order that they were
+// registered, of the runners whose shutdown functions had not returned once
+// the timeout elapsed, or nil if they all returned in time. Unlike
+// AwaitKillSignalsWithTimeout it does not force the process to exit, so it is
+// up to the caller to decide what to do, e.g. log the stragglers and exit:
+//
+//	if stuck := rununtil.AwaitKillSignalsParallelTimeout(signals, 10*time.Second, runners...); len(stuck) > 0 {
+//		log.Error().Msgf("runners %v did not shutdown in time", stuck)
+//		os.Exit(1)
+//	}
+//
+// The shutdown functions that are still running are abandoned. A panic in one
+// shutdown function does not prevent the others from completing; once they
+// have all returned, or the timeout has elapsed, the first panic is
+// propagated.
+func AwaitKillSignalsParallelTimeout(signals []os.Signal, timeout time.Duration, runnerFuncs ...RunnerFunc) []int {
+	wait, release := defaultGroup.listenForKillSignal(signals)
+	defer release()
+
+	shutdowns := startRunners(runnerFuncs)
+	wait()
+
+	return shutdownConcurrentlyWithin(timeout, shutdowns)
+}
+
+// shutdownConcurrentlyWithin executes each of the shutdown functions in its
+// own go routine and waits up to timeout for them all to return. It returns
+// the indices of the shutdown functions which had not returned in time, and
+// propagates the first panic.
+func shutdownConcurrentlyWithin(timeout time.Duration, shutdowns []ShutdownFunc) []int {
+	var mux sync.Mutex
+	returned := make([]bool, len(shutdowns))
+	var firstPanic interface{}
+
+	var wg sync.WaitGroup
+	for idx, shutdown := range shutdowns {
+		wg.Add(1)
+		go func(idx int, shutdown ShutdownFunc) {
+			defer wg.Done()
+			defer func() {
+				recovered := recover()
+				mux.Lock()
+				defer mux.Unlock()
+				returned[idx] = true
+				if recovered != nil && firstPanic == nil {
+					firstPanic = recovered
+				}
+			}()
+			shutdown()
+		}(idx, shutdown)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-done:
+	case <-timer.C:
+	}
+
+	mux.Lock()
+	defer mux.Unlock()
+	var stuck []int
+	for idx, ok := range returned {
+		if !ok {
+			stuck = append(stuck, idx)
+		}
+	}
+	if firstPanic != nil {
+		panic(firstPanic)
+	}
+	return stuck
+}
+
+// shutdownConcurrently executes each of the shutdown functions in its own go
+// routine and returns a channel which is closed once they have all returned.
+// Panics in the shutdown functions are recovered so that they cannot stop the
+// others from completing, and the first one is sent on the channel before it
+// is closed.
+func shutdownConcurrently(shutdowns []ShutdownFunc) <-chan interface{} {
+	var wg sync.WaitGroup
+	var once sync.Once
+	var firstPanic interface{}
+	for _, shutdown := range shutdowns {
+		wg.Add(1)
+		go func(shutdown ShutdownFunc) {
+			defer wg.Done()
+			defer func() {
+				if recovered := recover(); recovered != nil {
+					once.Do(func() { firstPanic = recovered })
+				}
+			}()
+			shutdown()
+		}(shutdown)
+	}
+
+	done := make(chan interface{}, 1)
+	go func() {
+		wg.Wait()
+		if firstPanic != nil {
+			done <- firstPanic
+		}
+		close(done)
+	}()
+	return done
+}
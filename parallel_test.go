@@ -0,0 +1,113 @@
+package rununtil_test
+
+import (
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/mec07/rununtil"
+)
+
+func TestRununtilAwaitKillSignalsParallel(t *testing.T) {
+	// each shutdown function blocks until the other one has started, so they
+	// can only complete if they are run concurrently
+	started1 := make(chan struct{})
+	started2 := make(chan struct{})
+	runner := func(mine, other chan struct{}) rununtil.RunnerFunc {
+		return rununtil.RunnerFunc(func() rununtil.ShutdownFunc {
+			return rununtil.ShutdownFunc(func() {
+				close(mine)
+				<-other
+			})
+		})
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		rununtil.AwaitKillSignalsParallel(
+			[]os.Signal{syscall.SIGINT},
+			runner(started1, started2),
+			runner(started2, started1),
+			helperMakeCancellingRunner(),
+		)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("expected the shutdown functions to have been run concurrently")
+	}
+}
+
+func TestRununtilAwaitKillSignalsParallel_Panic(t *testing.T) {
+	var hasBeenShutdown1, hasBeenShutdown2 bool
+	panickingRunner := rununtil.RunnerFunc(func() rununtil.ShutdownFunc {
+		return rununtil.ShutdownFunc(func() {
+			panic("shutdown failed")
+		})
+	})
+
+	defer func() {
+		if recovered := recover(); recovered != "shutdown failed" {
+			t.Fatalf("expected the panic to be propagated, got: %v", recovered)
+		}
+		if !hasBeenShutdown1 || !hasBeenShutdown2 {
+			t.Fatal("expected the other shutdown functions to have been called")
+		}
+	}()
+
+	rununtil.AwaitKillSignalsParallel(
+		[]os.Signal{syscall.SIGINT},
+		helperMakeFakeRunner(&hasBeenShutdown1),
+		panickingRunner,
+		helperMakeFakeRunner(&hasBeenShutdown2),
+		helperMakeCancellingRunner(),
+	)
+}
+
+func TestRununtilAwaitKillSignalsParallelTimeout(t *testing.T) {
+	blocked := make(chan struct{})
+	defer close(blocked)
+	stuckRunner := rununtil.RunnerFunc(func() rununtil.ShutdownFunc {
+		return rununtil.ShutdownFunc(func() {
+			<-blocked
+		})
+	})
+	var hasBeenShutdown bool
+
+	stuck := rununtil.AwaitKillSignalsParallelTimeout(
+		[]os.Signal{syscall.SIGINT},
+		10*time.Millisecond,
+		helperMakeFakeRunner(&hasBeenShutdown),
+		stuckRunner,
+		helperMakeCancellingRunner(),
+	)
+
+	if len(stuck) != 1 || stuck[0] != 1 {
+		t.Fatalf("expected only runner 1 to have not shutdown in time, got: %v", stuck)
+	}
+	if !hasBeenShutdown {
+		t.Fatal("expected the other shutdown function to have been called")
+	}
+}
+
+func TestRununtilAwaitKillSignalsParallelTimeout_AllInTime(t *testing.T) {
+	var hasBeenShutdown1, hasBeenShutdown2 bool
+
+	stuck := rununtil.AwaitKillSignalsParallelTimeout(
+		[]os.Signal{syscall.SIGINT},
+		time.Second,
+		helperMakeFakeRunner(&hasBeenShutdown1),
+		helperMakeFakeRunner(&hasBeenShutdown2),
+		helperMakeCancellingRunner(),
+	)
+
+	if stuck != nil {
+		t.Fatalf("expected all of the runners to have shutdown in time, got: %v", stuck)
+	}
+	if !hasBeenShutdown1 || !hasBeenShutdown2 {
+		t.Fatal("expected all the shutdown functions to have been called")
+	}
+}
@@ -0,0 +1,68 @@
+package rununtil
+
+import (
+	"sort"
+)
+
+// PhasedRunner is a RunnerFunc along with the phase of the shutdown in which
+// it is shutdown, for use with AwaitKillSignalsPhased.
+type PhasedRunner struct {
+	Phase int
+	Run   RunnerFunc
+}
+
+// AwaitKillSignalsPhased runs the provided PhasedRunners until it receives a
+// kill signal, SIGINT or SIGTERM, at which point it executes the graceful
+// shutdown functions phase by phase, in ascending order of phase. All of the
+// shutdown functions in a phase are executed concurrently, and the next phase
+// only starts once they have all returned. This is a middle ground between
+// shutting down in reverse order and a full dependency graph, e.g.:
+//
+//	rununtil.AwaitKillSignalsPhased(
+//		rununtil.PhasedRunner{Phase: 0, Run: NewHTTPServer(config)},
+//		rununtil.PhasedRunner{Phase: 1, Run: NewWorker(config)},
+//		rununtil.PhasedRunner{Phase: 1, Run: NewWorker(config)},
+//		rununtil.PhasedRunner{Phase: 2, Run: NewDB(config)},
+//	)
+//
+// The runners are started in the order that they were registered. A panic in
+// one shutdown function does not prevent the others, or the later phases,
+// from being executed; once they have all returned the first panic is
+// propagated.
+func AwaitKillSignalsPhased(runners ...PhasedRunner) {
+	defaultGroup.await(nil, []RunnerFunc{startPhased(runners)})
+}
+
+// startPhased returns a RunnerFunc which starts all of the runners, and whose
+// ShutdownFunc shuts them down phase by phase.
+func startPhased(runners []PhasedRunner) RunnerFunc {
+	return func() ShutdownFunc {
+		runnerFuncs := make([]RunnerFunc, len(runners))
+		for idx, runner := range runners {
+			runnerFuncs[idx] = runner.Run
+		}
+		shutdowns := startRunners(runnerFuncs)
+
+		phases := make(map[int][]ShutdownFunc)
+		var order []int
+		for idx, runner := range runners {
+			if _, ok := phases[runner.Phase]; !ok {
+				order = append(order, runner.Phase)
+			}
+			phases[runner.Phase] = append(phases[runner.Phase], shutdowns[idx])
+		}
+		sort.Ints(order)
+
+		return func() {
+			var firstPanic interface{}
+			for _, phase := range order {
+				if recovered := <-shutdownConcurrently(phases[phase]); recovered != nil && firstPanic == nil {
+					firstPanic = recovered
+				}
+			}
+			if firstPanic != nil {
+				panic(firstPanic)
+			}
+		}
+	}
+}
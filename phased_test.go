@@ -0,0 +1,70 @@
+package rununtil_test
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/mec07/rununtil"
+)
+
+func TestRununtilAwaitKillSignalsPhased(t *testing.T) {
+	var mux sync.Mutex
+	var phases []int
+	makeRunner := func(phase int, started, other chan struct{}) rununtil.PhasedRunner {
+		return rununtil.PhasedRunner{Phase: phase, Run: func() rununtil.ShutdownFunc {
+			return func() {
+				// runners given the other's channel block until it has
+				// started, so they can only complete if they run concurrently
+				if started != nil {
+					close(started)
+				}
+				if other != nil {
+					<-other
+				}
+				mux.Lock()
+				defer mux.Unlock()
+				phases = append(phases, phase)
+			}
+		}}
+	}
+	worker1 := make(chan struct{})
+	worker2 := make(chan struct{})
+
+	rununtil.AwaitKillSignalsPhased(
+		makeRunner(2, nil, nil),
+		makeRunner(0, nil, nil),
+		makeRunner(1, worker1, worker2),
+		makeRunner(1, worker2, worker1),
+		rununtil.PhasedRunner{Phase: 0, Run: helperMakeCancellingRunner()},
+	)
+
+	expected := []int{0, 1, 1, 2}
+	if !reflect.DeepEqual(phases, expected) {
+		t.Fatalf("expected phases %v, got: %v", expected, phases)
+	}
+}
+
+func TestRununtilAwaitKillSignalsPhased_Panic(t *testing.T) {
+	var hasBeenShutdown bool
+	panickingRunner := rununtil.RunnerFunc(func() rununtil.ShutdownFunc {
+		return func() {
+			panic("phase 0 failed")
+		}
+	})
+
+	defer func() {
+		if recovered := recover(); recovered != "phase 0 failed" {
+			t.Fatalf("expected the panic to be propagated, got: %v", recovered)
+		}
+		if !hasBeenShutdown {
+			t.Fatal("expected the later phase to have been shutdown")
+		}
+	}()
+
+	rununtil.AwaitKillSignalsPhased(
+		rununtil.PhasedRunner{Phase: 0, Run: panickingRunner},
+		rununtil.PhasedRunner{Phase: 1, Run: helperMakeFakeRunner(&hasBeenShutdown)},
+		rununtil.PhasedRunner{Phase: 0, Run: helperMakeCancellingRunner()},
+	)
+}
@@ -0,0 +1,18 @@
+package rununtil
+
+import (
+	"context"
+	"os"
+)
+
+// AwaitKillSignalsWithPreShutdown runs the provided RunnerFuncs until the
+// specified signals have been recieved, at which point it calls preShutdown
+// exactly once and then executes the graceful shutdown functions in the
+// reverse order to which the runners were registered. preShutdown is called
+// as soon as the signal has been received, before the first shutdown
+// function, and even if there are no runners, e.g. to flip a "shutting down"
+// flag so that the readiness probe fails and the load balancer takes the
+// instance out of rotation.
+func AwaitKillSignalsWithPreShutdown(signals []os.Signal, preShutdown func(ctx context.Context), runnerFuncs ...RunnerFunc) {
+	Await(runnerFuncs, WithSignals(signals...), WithPreShutdown(preShutdown))
+}
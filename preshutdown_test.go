@@ -0,0 +1,45 @@
+package rununtil_test
+
+import (
+	"context"
+	"os"
+	"reflect"
+	"syscall"
+	"testing"
+
+	"github.com/mec07/rununtil"
+)
+
+func TestRununtilAwaitKillSignalsWithPreShutdown(t *testing.T) {
+	var events []string
+	preShutdown := func(ctx context.Context) {
+		events = append(events, "pre-shutdown")
+	}
+	runner := rununtil.RunnerFunc(func() rununtil.ShutdownFunc {
+		return rununtil.ShutdownFunc(func() {
+			events = append(events, "shutdown")
+		})
+	})
+
+	rununtil.AwaitKillSignalsWithPreShutdown([]os.Signal{syscall.SIGINT}, preShutdown, runner, helperMakeCancellingRunner())
+
+	expected := []string{"pre-shutdown", "shutdown"}
+	if !reflect.DeepEqual(events, expected) {
+		t.Fatalf("expected events %v, got: %v", expected, events)
+	}
+}
+
+func TestRununtilAwaitKillSignalsWithPreShutdown_NoRunners(t *testing.T) {
+	var calls int
+	p, err := os.FindProcess(os.Getpid())
+	if err != nil {
+		t.Fatalf("Unexpected error when finding process: %v", err)
+	}
+
+	go helperSignalOnceListening(t, p, syscall.SIGINT)
+	rununtil.AwaitKillSignalsWithPreShutdown([]os.Signal{syscall.SIGINT}, func(ctx context.Context) { calls++ })
+
+	if calls != 1 {
+		t.Fatalf("expected the pre-shutdown hook to have been called once, got: %d", calls)
+	}
+}
@@ -0,0 +1,80 @@
+package rununtil
+
+import (
+	"os"
+	"time"
+)
+
+// ShutdownEvent reports the progress of the shutdown function of a single
+// runner.
+type ShutdownEvent struct {
+	// Index is the index of the runner in the order that they were registered.
+	Index int
+	// Name is the name of the runner.
+	Name string
+	// Completed is false when the shutdown function starts and true once it
+	// has returned.
+	Completed bool
+	// Duration is how long the shutdown function took to return, once it has
+	// completed.
+	Duration time.Duration
+}
+
+// AwaitKillSignalsProgress runs the provided NamedRunners until the specified
+// signals have been recieved, at which point it executes the graceful shutdown
+// functions in the same order as AwaitKillSignalsGraph. A ShutdownEvent is
+// sent on progress as each shutdown function starts and completes, e.g. to
+// show the progress of the shutdown on an admin dashboard:
+//
+//	progress := make(chan rununtil.ShutdownEvent, 16)
+//	go func() {
+//		for event := range progress {
+//			dashboard.Update(event)
+//		}
+//	}()
+//	err := rununtil.AwaitKillSignalsProgress(signals, progress, runners...)
+//
+// The events are sent without blocking, so they are dropped if the consumer
+// is not keeping up rather than holding up the shutdown; use a buffered
+// channel to avoid losing them. The channel is closed once the shutdown has
+// finished. If the dependencies can't be satisfied an error is returned, and
+// the channel closed, without starting any of the runners.
+func AwaitKillSignalsProgress(signals []os.Signal, progress chan<- ShutdownEvent, runners ...NamedRunner) error {
+	defer close(progress)
+
+	reporting := make([]NamedRunner, len(runners))
+	for idx, runner := range runners {
+		reporting[idx] = runner
+		reporting[idx].Run = reportProgress(progress, idx, runner.Name, runner.Run)
+	}
+	ordered, err := sortByDependencies(reporting)
+	if err != nil {
+		return err
+	}
+
+	defaultGroup.await([]Option{WithSignals(signals...)}, ordered)
+	return nil
+}
+
+// reportProgress wraps the runner so that its shutdown function sends a
+// ShutdownEvent on progress when it starts and when it completes.
+func reportProgress(progress chan<- ShutdownEvent, idx int, name string, runner RunnerFunc) RunnerFunc {
+	send := func(event ShutdownEvent) {
+		select {
+		case progress <- event:
+		default:
+		}
+	}
+
+	return func() ShutdownFunc {
+		shutdown := noopIfNil(runner())
+		return func() {
+			send(ShutdownEvent{Index: idx, Name: name})
+			start := time.Now()
+			defer func() {
+				send(ShutdownEvent{Index: idx, Name: name, Completed: true, Duration: time.Since(start)})
+			}()
+			shutdown()
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package rununtil_test
+
+import (
+	"os"
+	"reflect"
+	"syscall"
+	"testing"
+
+	"github.com/mec07/rununtil"
+)
+
+func TestRununtilAwaitKillSignalsProgress(t *testing.T) {
+	var order []int
+	progress := make(chan rununtil.ShutdownEvent, 16)
+
+	err := rununtil.AwaitKillSignalsProgress(
+		[]os.Signal{syscall.SIGINT},
+		progress,
+		rununtil.NamedRunner{Name: "http", DependsOn: []string{"db"}, Run: helperMakeOrderedRunner(1, &order)},
+		rununtil.NamedRunner{Name: "db", Run: helperMakeOrderedRunner(2, &order)},
+		rununtil.NamedRunner{Name: "cancel", Run: helperMakeCancellingRunner()},
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	type step struct {
+		Index     int
+		Name      string
+		Completed bool
+	}
+	var steps []step
+	for event := range progress {
+		steps = append(steps, step{event.Index, event.Name, event.Completed})
+	}
+	expected := []step{
+		{2, "cancel", false}, {2, "cancel", true},
+		{0, "http", false}, {0, "http", true},
+		{1, "db", false}, {1, "db", true},
+	}
+	if !reflect.DeepEqual(steps, expected) {
+		t.Fatalf("expected events %v, got: %v", expected, steps)
+	}
+}
+
+func TestRununtilAwaitKillSignalsProgress_SlowConsumer(t *testing.T) {
+	var hasBeenShutdown1, hasBeenShutdown2 bool
+	progress := make(chan rununtil.ShutdownEvent)
+
+	err := rununtil.AwaitKillSignalsProgress(
+		[]os.Signal{syscall.SIGINT},
+		progress,
+		rununtil.NamedRunner{Name: "one", Run: helperMakeFakeRunner(&hasBeenShutdown1)},
+		rununtil.NamedRunner{Name: "two", Run: helperMakeFakeRunner(&hasBeenShutdown2)},
+		rununtil.NamedRunner{Name: "cancel", Run: helperMakeCancellingRunner()},
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !hasBeenShutdown1 || !hasBeenShutdown2 {
+		t.Fatal("expected the shutdown to complete even though nobody was receiving the events")
+	}
+	if _, ok := <-progress; ok {
+		t.Fatal("expected the progress channel to have been closed")
+	}
+}
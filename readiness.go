@@ -0,0 +1,52 @@
+package rununtil
+
+import "net/http"
+
+// ReadinessCheck reports whether the process is ready to receive traffic,
+// which it is until a shutdown has been initiated. Use Readiness to create
+// one.
+type ReadinessCheck struct {
+	group *Group
+}
+
+// Readiness returns a ReadinessCheck which flips to not ready as soon as a
+// shutdown has been initiated on the Group, so that, e.g., Kubernetes stops
+// routing traffic to the process while the shutdown functions are draining
+// the in-flight requests:
+//
+//	readiness := group.Readiness()
+//	r.Get("/ready", readiness.Handler())
+func (g *Group) Readiness() *ReadinessCheck {
+	return &ReadinessCheck{group: g}
+}
+
+// Readiness returns a ReadinessCheck which flips to not ready as soon as a
+// shutdown has been initiated on the default Group, see Group.Readiness.
+func Readiness() *ReadinessCheck {
+	return defaultGroup.Readiness()
+}
+
+// IsReady returns false once a shutdown has been initiated, and true before.
+// It becomes true again if a new await is started on the Group once the
+// shutdown has finished.
+func (r *ReadinessCheck) IsReady() bool {
+	select {
+	case <-r.group.ShutdownInitiated():
+		return false
+	default:
+		return true
+	}
+}
+
+// Handler returns an http.HandlerFunc which responds with 200 OK while the
+// process is ready and 503 Service Unavailable once a shutdown has been
+// initiated.
+func (r *ReadinessCheck) Handler() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		if !r.IsReady() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}
+}
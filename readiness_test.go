@@ -0,0 +1,58 @@
+package rununtil_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mec07/rununtil"
+)
+
+func helperReadinessStatus(readiness *rununtil.ReadinessCheck) int {
+	rec := httptest.NewRecorder()
+	readiness.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/ready", nil))
+	return rec.Code
+}
+
+func TestGroupReadiness(t *testing.T) {
+	group := rununtil.NewGroup()
+	readiness := group.Readiness()
+	var readyWhileRunning bool
+	var statusWhileRunning, statusDuringShutdown int
+	runner := rununtil.RunnerFunc(func() rununtil.ShutdownFunc {
+		readyWhileRunning = readiness.IsReady()
+		statusWhileRunning = helperReadinessStatus(readiness)
+		group.SimulateKillSignal()
+		return rununtil.ShutdownFunc(func() {
+			statusDuringShutdown = helperReadinessStatus(readiness)
+		})
+	})
+
+	group.AwaitKillSignal(runner)
+
+	if !readyWhileRunning {
+		t.Fatal("expected to be ready before the shutdown was initiated")
+	}
+	if statusWhileRunning != http.StatusOK {
+		t.Fatalf("expected status %d while running, got: %d", http.StatusOK, statusWhileRunning)
+	}
+	if statusDuringShutdown != http.StatusServiceUnavailable {
+		t.Fatalf("expected status %d during shutdown, got: %d", http.StatusServiceUnavailable, statusDuringShutdown)
+	}
+	if readiness.IsReady() {
+		t.Fatal("did not expect to be ready once the shutdown was initiated")
+	}
+}
+
+func TestRununtilReadiness(t *testing.T) {
+	readiness := rununtil.Readiness()
+
+	rununtil.SimulateKillSignal()
+
+	if readiness.IsReady() {
+		t.Fatal("did not expect to be ready once the shutdown was initiated")
+	}
+	if status := helperReadinessStatus(readiness); status != http.StatusServiceUnavailable {
+		t.Fatalf("expected status %d, got: %d", http.StatusServiceUnavailable, status)
+	}
+}
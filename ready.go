@@ -0,0 +1,58 @@
+package rununtil
+
+import (
+	"os"
+	"sync"
+	"sync/atomic"
+)
+
+// RunnerFuncReady is a nonblocking function that sets off the worker go
+// routines and returns a function which can shutdown those worker go routines.
+// It must call the ready function once the worker go routines are up and
+// running, e.g. once an HTTP server is listening. It is safe to call ready
+// from any go routine and to call it more than once.
+type RunnerFuncReady func(ready func()) ShutdownFunc
+
+// AwaitKillSignalReady runs the provided RunnerFuncReadys until it receives a
+// kill signal, SIGINT or SIGTERM, at which point it executes the graceful
+// shutdown functions. The onAllReady function is called once every runner has
+// called its ready function.
+func AwaitKillSignalReady(onAllReady func(), runnerFuncs ...RunnerFuncReady) {
+	AwaitKillSignalsReady(defaultSignals(), onAllReady, runnerFuncs...)
+}
+
+// AwaitKillSignalsReady runs the provided RunnerFuncReadys until the specified
+// signals have been recieved, at which point it executes the graceful shutdown
+// functions. The onAllReady function is called exactly once, from the go
+// routine which makes the final call to ready, as soon as every runner has
+// called its ready function. If there are no runners it is called straight
+// away.
+func AwaitKillSignalsReady(signals []os.Signal, onAllReady func(), runnerFuncs ...RunnerFuncReady) {
+	wait, release := defaultGroup.listenForKillSignal(signals)
+	defer release()
+
+	remaining := int32(len(runnerFuncs))
+	runners := make([]RunnerFunc, 0, len(runnerFuncs))
+	for _, runner := range runnerFuncs {
+		runner := runner
+		var once sync.Once
+		ready := func() {
+			once.Do(func() {
+				if atomic.AddInt32(&remaining, -1) == 0 {
+					onAllReady()
+				}
+			})
+		}
+		runners = append(runners, RunnerFunc(func() ShutdownFunc {
+			return runner(ready)
+		}))
+	}
+	shutdowns := startRunners(runners)
+	if len(runnerFuncs) == 0 {
+		onAllReady()
+	}
+
+	wait()
+
+	shutdownWithinDefaultTimeout(func() { shutdownInReverse(shutdowns) })
+}
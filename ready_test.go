@@ -0,0 +1,74 @@
+package rununtil_test
+
+import (
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/mec07/rununtil"
+)
+
+func TestRununtilAwaitKillSignalsReady(t *testing.T) {
+	var onAllReadyCalls int
+	allReady := make(chan struct{})
+	onAllReady := func() {
+		onAllReadyCalls++
+		close(allReady)
+	}
+
+	// one runner is ready straight away and calls ready more than once, the
+	// other only becomes ready once it is told to
+	readyNow := rununtil.RunnerFuncReady(func(ready func()) rununtil.ShutdownFunc {
+		ready()
+		ready()
+		return rununtil.ShutdownFunc(func() {})
+	})
+	makeReady := make(chan struct{})
+	readyLater := rununtil.RunnerFuncReady(func(ready func()) rununtil.ShutdownFunc {
+		go func() {
+			<-makeReady
+			ready()
+		}()
+		return rununtil.ShutdownFunc(func() {})
+	})
+
+	done := make(chan struct{})
+	go func() {
+		rununtil.AwaitKillSignalsReady([]os.Signal{syscall.SIGINT}, onAllReady, readyNow, readyLater)
+		close(done)
+	}()
+
+	select {
+	case <-allReady:
+		t.Fatal("did not expect onAllReady to be called before every runner is ready")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	close(makeReady)
+	select {
+	case <-allReady:
+	case <-time.After(time.Second):
+		t.Fatal("expected onAllReady to have been called")
+	}
+
+	rununtil.CancelAll()
+	<-done
+	if onAllReadyCalls != 1 {
+		t.Fatalf("expected onAllReady to have been called once, got: %d", onAllReadyCalls)
+	}
+}
+
+func TestRununtilAwaitKillSignalsReady_NoRunners(t *testing.T) {
+	var onAllReadyCalled bool
+	onAllReady := func() {
+		onAllReadyCalled = true
+		rununtil.CancelAll()
+	}
+
+	rununtil.AwaitKillSignalsReady([]os.Signal{syscall.SIGINT}, onAllReady)
+
+	if !onAllReadyCalled {
+		t.Fatal("expected onAllReady to have been called")
+	}
+}
@@ -0,0 +1,45 @@
+package rununtil
+
+// ShutdownReason describes what triggered a shutdown.
+type ShutdownReason int
+
+const (
+	// ReasonUnknown is the zero value, which means that the reason has not
+	// been set, e.g. in a Result which did not come from a shutdown.
+	ReasonUnknown ShutdownReason = iota
+	// ReasonSignal means that one of the kill signals was received, e.g. an
+	// operator initiated the shutdown.
+	ReasonSignal
+	// ReasonSimulated means that SimulateKillSignal or CancelAll was called,
+	// e.g. by a test.
+	ReasonSimulated
+	// ReasonContext means that the context passed to the await, e.g. with
+	// WithContext, was cancelled.
+	ReasonContext
+	// ReasonStdinEOF means that stdin was closed, see WithStdinEOFShutdown.
+	ReasonStdinEOF
+	// ReasonStopped means that the Group was stopped from within the
+	// process, e.g. by Stop, because a server failed to serve, or because a
+	// go routine started with Group.Go returned an error.
+	ReasonStopped
+	// ReasonJobDone means that the function run by Job returned.
+	ReasonJobDone
+)
+
+func (r ShutdownReason) String() string {
+	switch r {
+	case ReasonSignal:
+		return "signal"
+	case ReasonSimulated:
+		return "simulated"
+	case ReasonContext:
+		return "context"
+	case ReasonStdinEOF:
+		return "stdin EOF"
+	case ReasonStopped:
+		return "stopped"
+	case ReasonJobDone:
+		return "job done"
+	}
+	return "unknown"
+}
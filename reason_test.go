@@ -0,0 +1,111 @@
+package rununtil_test
+
+import (
+	"context"
+	"os"
+	"syscall"
+	"testing"
+
+	"github.com/mec07/rununtil"
+)
+
+func TestRununtilAwaitReason(t *testing.T) {
+	p, err := os.FindProcess(os.Getpid())
+	if err != nil {
+		t.Fatalf("Unexpected error when finding process: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancellingCtxRunner := rununtil.RunnerFunc(func() rununtil.ShutdownFunc {
+		cancel()
+		return nil
+	})
+	signallingRunner := rununtil.RunnerFunc(func() rununtil.ShutdownFunc {
+		go helperSignalOnceListening(t, p, syscall.SIGINT)
+		return nil
+	})
+
+	table := []struct {
+		name     string
+		runner   rununtil.RunnerFunc
+		expected rununtil.ShutdownReason
+	}{
+		{
+			name:     "Signal",
+			runner:   signallingRunner,
+			expected: rununtil.ReasonSignal,
+		},
+		{
+			name:     "Simulated",
+			runner:   helperMakeCancellingRunner(),
+			expected: rununtil.ReasonSimulated,
+		},
+		{
+			name: "Stopped",
+			runner: rununtil.RunnerFunc(func() rununtil.ShutdownFunc {
+				rununtil.DefaultGroup().Stop()
+				return nil
+			}),
+			expected: rununtil.ReasonStopped,
+		},
+		{
+			name: "JobDone",
+			runner: rununtil.Job(func(ctx context.Context) error {
+				return nil
+			}),
+			expected: rununtil.ReasonJobDone,
+		},
+		// last, as it cancels the context shared by all of the cases
+		{
+			name:     "Context",
+			runner:   cancellingCtxRunner,
+			expected: rununtil.ReasonContext,
+		},
+	}
+	for _, test := range table {
+		t.Run(test.name, func(t *testing.T) {
+			var hookReason rununtil.ShutdownReason
+			hooks := rununtil.Hooks{
+				OnShutdownReason: func(reason rununtil.ShutdownReason) {
+					hookReason = reason
+				},
+			}
+
+			reason := rununtil.AwaitReason(
+				[]rununtil.RunnerFunc{test.runner},
+				rununtil.WithSignals(syscall.SIGINT),
+				rununtil.WithContext(ctx),
+				rununtil.WithHooks(hooks),
+			)
+
+			if reason != test.expected {
+				t.Fatalf("expected reason %v, got: %v", test.expected, reason)
+			}
+			if hookReason != test.expected {
+				t.Fatalf("expected the hook to be called with %v, got: %v", test.expected, hookReason)
+			}
+		})
+	}
+}
+
+func TestShutdownReason_ZeroValue(t *testing.T) {
+	var reason rununtil.ShutdownReason
+	if reason != rununtil.ReasonUnknown {
+		t.Fatalf("expected the zero value to be ReasonUnknown, got: %v", reason)
+	}
+	if reason.String() != "unknown" {
+		t.Fatalf("expected the zero value to be described as unknown, got: %q", reason.String())
+	}
+}
+
+func TestRununtilAwaitKillSignalsResult_Stopped(t *testing.T) {
+	stoppingRunner := rununtil.RunnerFuncE(func() rununtil.ShutdownFuncE {
+		rununtil.DefaultGroup().Stop()
+		return nil
+	})
+
+	result := rununtil.AwaitKillSignalsResult([]os.Signal{syscall.SIGINT}, stoppingRunner)
+
+	if result.Reason != rununtil.ReasonStopped {
+		t.Fatalf("expected ReasonStopped, got: %v", result.Reason)
+	}
+}
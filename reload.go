@@ -0,0 +1,34 @@
+package rununtil
+
+import (
+	"os/signal"
+	"syscall"
+)
+
+// AwaitKillSignalWithReload runs the provided RunnerFuncs until it receives a
+// kill signal, SIGINT or SIGTERM, at which point it executes the graceful
+// shutdown functions. Every time a SIGHUP is received the reload function is
+// called instead, e.g. to reload the config, and then it carries on waiting.
+// The reload function is called synchronously, so there are never two
+// concurrent invocations of it, and any signals which arrive while it is
+// running are handled once it has returned.
+func AwaitKillSignalWithReload(reload func(), runnerFuncs ...RunnerFunc) {
+	c, finish, release := defaultGroup.killSignalChannels(signal.Notify, append(defaultSignals(), syscall.SIGHUP))
+	defer release()
+
+	shutdowns := startRunners(runnerFuncs)
+
+	for {
+		select {
+		case sig := <-c:
+			if sig == syscall.SIGHUP {
+				reload()
+				continue
+			}
+		case <-finish:
+		}
+		defaultGroup.initiateShutdown()
+		shutdownWithinDefaultTimeout(func() { shutdownInReverse(shutdowns) })
+		return
+	}
+}
@@ -0,0 +1,52 @@
+package rununtil_test
+
+import (
+	"os"
+	"syscall"
+	"testing"
+
+	"github.com/mec07/rununtil"
+)
+
+func TestRununtilAwaitKillSignalWithReload(t *testing.T) {
+	p, err := os.FindProcess(os.Getpid())
+	if err != nil {
+		t.Fatalf("Unexpected error when finding process: %v", err)
+	}
+	sendSignal := func(sig os.Signal) {
+		if err := p.Signal(sig); err != nil {
+			t.Errorf("unexpected error occurred: %v", err)
+		}
+	}
+
+	var reloads, running int
+	var shutdownAfterReloads int
+	reload := func() {
+		running++
+		if running > 1 {
+			t.Error("did not expect reload to be called concurrently")
+		}
+		reloads++
+		if reloads < 3 {
+			sendSignal(syscall.SIGHUP)
+		} else {
+			sendSignal(syscall.SIGTERM)
+		}
+		running--
+	}
+	runner := rununtil.RunnerFunc(func() rununtil.ShutdownFunc {
+		sendSignal(syscall.SIGHUP)
+		return rununtil.ShutdownFunc(func() {
+			shutdownAfterReloads = reloads
+		})
+	})
+
+	rununtil.AwaitKillSignalWithReload(reload, runner)
+
+	if reloads != 3 {
+		t.Fatalf("expected reload to have been called 3 times, got: %d", reloads)
+	}
+	if shutdownAfterReloads != 3 {
+		t.Fatal("expected the shutdown function to have been called after the reloads")
+	}
+}
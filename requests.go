@@ -0,0 +1,105 @@
+package rununtil
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ActiveRequests counts the requests which are in flight, so that the
+// shutdown can wait for them to complete rather than cutting them off, which
+// http.Server.Shutdown alone does not do for hijacked or streaming
+// connections. The zero value is ready to use, and it is safe to use from
+// several go routines.
+type ActiveRequests struct {
+	mux   sync.Mutex
+	count int
+	// idle is closed once the count drops back to zero.
+	idle chan struct{}
+}
+
+// Add records that a request has started.
+func (a *ActiveRequests) Add() {
+	a.mux.Lock()
+	defer a.mux.Unlock()
+	if a.count == 0 {
+		a.idle = make(chan struct{})
+	}
+	a.count++
+}
+
+// Done records that a request has completed. It panics if it is called more
+// times than Add, in the same way as sync.WaitGroup.
+func (a *ActiveRequests) Done() {
+	a.mux.Lock()
+	defer a.mux.Unlock()
+	if a.count == 0 {
+		panic("rununtil: ActiveRequests.Done called more times than Add")
+	}
+	a.count--
+	if a.count == 0 {
+		close(a.idle)
+	}
+}
+
+// Count returns the number of requests which are in flight.
+func (a *ActiveRequests) Count() int {
+	a.mux.Lock()
+	defer a.mux.Unlock()
+	return a.count
+}
+
+// Wait blocks until there are no requests in flight, or until ctx is done, in
+// which case it returns an error saying how many requests are still in flight.
+func (a *ActiveRequests) Wait(ctx context.Context) error {
+	a.mux.Lock()
+	if a.count == 0 {
+		a.mux.Unlock()
+		return nil
+	}
+	idle := a.idle
+	a.mux.Unlock()
+
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+		return errors.Wrapf(ctx.Err(), "%d request(s) still in flight", a.Count())
+	}
+}
+
+// Middleware counts the requests handled by next as being in flight until
+// next has returned.
+func (a *ActiveRequests) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		a.Add()
+		defer a.Done()
+		next.ServeHTTP(w, r)
+	})
+}
+
+// DrainWait returns a DrainWait which waits for the requests in flight to
+// complete, for at most max, so that none of them are cut off by the shutdown
+// functions:
+//
+//	var requests rununtil.ActiveRequests
+//	handler := requests.Middleware(router)
+//	...
+//	rununtil.AwaitKillSignalsDrainWait(signals, requests.DrainWait(10*time.Second), NewRunner(handler))
+//
+// If there are still requests in flight once max has elapsed, the error is
+// printed to stderr and the shutdown carries on regardless.
+func (a *ActiveRequests) DrainWait(max time.Duration) DrainWait {
+	return func() {
+		ctx, cancel := context.WithTimeout(context.Background(), max)
+		defer cancel()
+		if err := a.Wait(ctx); err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: %+v\n", errors.Wrap(err, "draining requests"))
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package rununtil_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/mec07/rununtil"
+	"github.com/pkg/errors"
+)
+
+func TestActiveRequests_Wait(t *testing.T) {
+	var requests rununtil.ActiveRequests
+	if err := requests.Wait(context.Background()); err != nil {
+		t.Fatalf("expected Wait to return straight away with no requests in flight, got: %v", err)
+	}
+
+	requests.Add()
+	requests.Add()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := requests.Wait(ctx); errors.Cause(err) != context.DeadlineExceeded {
+		t.Fatalf("expected Wait to give up at the deadline, got: %v", err)
+	}
+
+	waited := make(chan error)
+	go func() { waited <- requests.Wait(context.Background()) }()
+	requests.Done()
+	requests.Done()
+	select {
+	case err := <-waited:
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected Wait to return once the requests had completed")
+	}
+	if count := requests.Count(); count != 0 {
+		t.Fatalf("expected no requests in flight, got: %d", count)
+	}
+}
+
+func TestActiveRequests_Middleware(t *testing.T) {
+	var requests rununtil.ActiveRequests
+	var inFlight int
+	handler := requests.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		inFlight = requests.Count()
+	}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if inFlight != 1 {
+		t.Fatalf("expected the request to be in flight while it was handled, got: %d", inFlight)
+	}
+	if count := requests.Count(); count != 0 {
+		t.Fatalf("expected the request to have completed, got: %d in flight", count)
+	}
+}
+
+func TestActiveRequests_DrainWait(t *testing.T) {
+	var requests rununtil.ActiveRequests
+	var events []string
+	requestRunner := rununtil.TwoPhaseRunnerFunc(func() (rununtil.DrainFunc, rununtil.ShutdownFunc) {
+		requests.Add()
+		drain := func() {
+			go func() {
+				time.Sleep(10 * time.Millisecond)
+				events = append(events, "request completed")
+				requests.Done()
+			}()
+		}
+		shutdown := func() {
+			events = append(events, "shutdown")
+		}
+		rununtil.CancelAll()
+		return drain, shutdown
+	})
+
+	rununtil.AwaitKillSignalsDrainWait([]os.Signal{syscall.SIGINT}, requests.DrainWait(time.Second), requestRunner)
+
+	expected := []string{"request completed", "shutdown"}
+	if !reflect.DeepEqual(events, expected) {
+		t.Fatalf("expected events %v, got: %v", expected, events)
+	}
+}
@@ -0,0 +1,50 @@
+package rununtil
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// reraise restores the default behaviour for the signal and sends it to the
+// current process, so that the process terminates as though it had been
+// killed by the signal. It is a variable so that it can be stubbed in tests.
+var reraise = func(sig os.Signal) error {
+	signal.Reset(sig)
+
+	p, err := os.FindProcess(os.Getpid())
+	if err != nil {
+		return errors.Wrap(err, "trying to get PID")
+	}
+	if err := p.Signal(sig); err != nil {
+		return errors.Wrapf(err, "sending %v", sig)
+	}
+
+	// give the signal a chance to be delivered before returning
+	time.Sleep(time.Second)
+	return nil
+}
+
+// AwaitKillSignalsReraise runs the provided RunnerFuncs until the specified
+// signals have been recieved, at which point it executes the graceful shutdown
+// functions in the reverse order to which the runners were registered. Once
+// the shutdown functions have returned, the default behaviour for the signal
+// that was received is restored and the signal is sent to the process again,
+// so that, per Unix convention, the parent process sees that it was killed by
+// that signal. Note that this resets the signal for the whole process.
+// If the shutdown was triggered by SimulateKillSignal or CancelAll there is no
+// signal to re-raise, so it just returns.
+func AwaitKillSignalsReraise(signals []os.Signal, runnerFuncs ...RunnerFunc) {
+	Await(runnerFuncs, WithSignals(signals...), WithReraise())
+}
+
+// reraiseOrReport re-raises the signal, reporting any error which prevented it
+// from being re-raised.
+func reraiseOrReport(sig os.Signal) {
+	if err := reraise(sig); err != nil {
+		fmt.Printf("ERROR: %+v\n", errors.Wrap(err, "re-raising signal"))
+	}
+}
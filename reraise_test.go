@@ -0,0 +1,62 @@
+package rununtil_test
+
+import (
+	"os"
+	"syscall"
+	"testing"
+
+	"github.com/mec07/rununtil"
+)
+
+func TestRununtilAwaitKillSignalsReraise(t *testing.T) {
+	var reraised os.Signal
+	var shutdownBeforeReraise bool
+	var hasBeenShutdown bool
+	restore := rununtil.SetReraise(func(sig os.Signal) error {
+		reraised = sig
+		shutdownBeforeReraise = hasBeenShutdown
+		return nil
+	})
+	defer restore()
+
+	p, err := os.FindProcess(os.Getpid())
+	if err != nil {
+		t.Fatalf("Unexpected error when finding process: %v", err)
+	}
+
+	go helperSignalOnceListening(t, p, syscall.SIGTERM)
+	rununtil.AwaitKillSignalsReraise(
+		[]os.Signal{syscall.SIGINT, syscall.SIGTERM},
+		helperMakeFakeRunner(&hasBeenShutdown),
+	)
+
+	if reraised != syscall.SIGTERM {
+		t.Fatalf("expected SIGTERM to have been re-raised, got: %v", reraised)
+	}
+	if !shutdownBeforeReraise {
+		t.Fatal("expected the shutdown function to have been called before re-raising")
+	}
+}
+
+func TestRununtilAwaitKillSignalsReraise_Simulated(t *testing.T) {
+	var reraiseCalled bool
+	restore := rununtil.SetReraise(func(sig os.Signal) error {
+		reraiseCalled = true
+		return nil
+	})
+	defer restore()
+
+	var hasBeenShutdown bool
+	rununtil.AwaitKillSignalsReraise(
+		[]os.Signal{syscall.SIGINT, syscall.SIGTERM},
+		helperMakeFakeRunner(&hasBeenShutdown),
+		helperMakeCancellingRunner(),
+	)
+
+	if !hasBeenShutdown {
+		t.Fatal("expected the shutdown function to have been called")
+	}
+	if reraiseCalled {
+		t.Fatal("did not expect a simulated kill signal to be re-raised")
+	}
+}
@@ -0,0 +1,35 @@
+package rununtil
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Retry wraps the runner so that its startup is retried if it fails, e.g.
+// because the database it connects to is not ready yet, which is common when
+// the containers of a system are all started at once:
+//
+//	rununtil.AwaitKillSignalErr(rununtil.Retry(5, time.Second, NewDBRunner(config)))
+//
+// The runner is started at most attempts times, waiting backoff before the
+// first retry and doubling the wait after each one. If none of the attempts
+// succeed the error from the last one is returned, wrapped with the number of
+// attempts.
+func Retry(attempts int, backoff time.Duration, runner RunnerFuncErr) RunnerFuncErr {
+	return func() (ShutdownFunc, error) {
+		var err error
+		for attempt := 1; ; attempt++ {
+			var shutdown ShutdownFunc
+			shutdown, err = runner()
+			if err == nil {
+				return shutdown, nil
+			}
+			if attempt >= attempts {
+				return nil, errors.Wrapf(err, "giving up after %d attempts", attempt)
+			}
+			time.Sleep(backoff)
+			backoff *= 2
+		}
+	}
+}
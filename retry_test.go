@@ -0,0 +1,53 @@
+package rununtil_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mec07/rununtil"
+	"github.com/pkg/errors"
+)
+
+func helperMakeFlakyRunner(failures int, attempts *int, hasBeenShutdown *bool) rununtil.RunnerFuncErr {
+	return rununtil.RunnerFuncErr(func() (rununtil.ShutdownFunc, error) {
+		*attempts++
+		if *attempts <= failures {
+			return nil, errors.New("database not ready")
+		}
+		return rununtil.ShutdownFunc(func() {
+			*hasBeenShutdown = true
+		}), nil
+	})
+}
+
+func TestRetry(t *testing.T) {
+	var attempts int
+	var hasBeenShutdown bool
+
+	shutdown, err := rununtil.Retry(3, time.Millisecond, helperMakeFlakyRunner(2, &attempts, &hasBeenShutdown))()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if attempts != 3 {
+		t.Fatalf("expected the runner to have been started 3 times, got: %d", attempts)
+	}
+	shutdown()
+	if !hasBeenShutdown {
+		t.Fatal("expected the shutdown function of the successful attempt to have been returned")
+	}
+}
+
+func TestRetry_GivesUp(t *testing.T) {
+	var attempts int
+	var hasBeenShutdown bool
+
+	_, err := rununtil.Retry(3, time.Millisecond, helperMakeFlakyRunner(5, &attempts, &hasBeenShutdown))()
+
+	expected := "giving up after 3 attempts: database not ready"
+	if err == nil || err.Error() != expected {
+		t.Fatalf("expected error %q, got: %v", expected, err)
+	}
+	if attempts != 3 {
+		t.Fatalf("expected the runner to have been started 3 times, got: %d", attempts)
+	}
+}
@@ -0,0 +1,53 @@
+package rununtil
+
+import (
+	"os"
+	"os/signal"
+)
+
+// Runners holds the ShutdownFuncs of runners which were started by
+// StartRunners, until Wait shuts them down.
+type Runners struct {
+	shutdowns  []ShutdownFunc
+	generation uint64
+}
+
+// StartRunners runs the provided RunnerFuncs and returns a Runners holding
+// their ShutdownFuncs, without blocking. This separates starting the runners
+// from awaiting a kill signal, so that main can do some work in between:
+//
+//	runners := rununtil.StartRunners(NewRunner(logger))
+//	log.Info().Msg("ready to serve")
+//	runners.Wait()
+//
+// If one of the runners panics, the runners which have already started are
+// shutdown before the panic is propagated.
+func StartRunners(runnerFuncs ...RunnerFunc) *Runners {
+	generation := defaultGroup.canceller.currentGeneration()
+	return &Runners{
+		shutdowns:  startRunners(runnerFuncs),
+		generation: generation,
+	}
+}
+
+// Wait blocks until the specified signals have been recieved, or until SIGINT
+// or SIGTERM if none are specified, at which point it executes the graceful
+// shutdown functions in the reverse order to which the runners were
+// registered. A SimulateKillSignal or CancelAll which happens after
+// StartRunners but before Wait stops it straight away, but note that the
+// signals are only caught once Wait has been called.
+func (r *Runners) Wait(signals ...os.Signal) {
+	if len(signals) == 0 {
+		signals = defaultSignals()
+	}
+	c, finish, release := defaultGroup.killSignalChannelsSince(signal.Notify, signals, r.generation)
+	defer release()
+
+	select {
+	case <-c:
+	case <-finish:
+	}
+	defaultGroup.initiateShutdown()
+
+	shutdownWithinDefaultTimeout(func() { shutdownInReverse(r.shutdowns) })
+}
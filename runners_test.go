@@ -0,0 +1,56 @@
+package rununtil_test
+
+import (
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/mec07/rununtil"
+)
+
+func TestRununtilStartRunners(t *testing.T) {
+	var order []int
+	runners := rununtil.StartRunners(
+		helperMakeOrderedRunner(1, &order),
+		helperMakeOrderedRunner(2, &order),
+	)
+	if len(order) != 0 {
+		t.Fatal("did not expect the runners to have been shutdown before Wait")
+	}
+
+	// the signal is only caught once Wait has been called, so only send it
+	// once Wait is listening
+	p, err := os.FindProcess(os.Getpid())
+	if err != nil {
+		t.Fatalf("Unexpected error when finding process: %v", err)
+	}
+	go helperSignalOnceListening(t, p, syscall.SIGINT)
+	runners.Wait(syscall.SIGINT)
+
+	if len(order) != 2 || order[0] != 2 || order[1] != 1 {
+		t.Fatalf("expected the runners to be shutdown in reverse order, got: %v", order)
+	}
+}
+
+func TestRununtilStartRunners_CancelledBeforeWait(t *testing.T) {
+	var hasBeenShutdown bool
+	runners := rununtil.StartRunners(helperMakeFakeRunner(&hasBeenShutdown))
+
+	rununtil.CancelAll()
+
+	done := make(chan struct{})
+	go func() {
+		runners.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		rununtil.CancelAll()
+		t.Fatal("expected a CancelAll before Wait to stop it straight away")
+	}
+	if !hasBeenShutdown {
+		t.Fatal("expected the shutdown function to have been called")
+	}
+}
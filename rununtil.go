@@ -2,7 +2,7 @@
 
 Usage
 
-The main usage of rununtil is to run your main app indefinitely until a SIGINT or SIGTERM signal has been received.
+The main usage of rununtil is to run your main app indefinitely until a SIGINT or SIGTERM signal has been received (on Windows, where SIGTERM is never delivered, an os.Interrupt signal).
 The `AwaitKillSignal` is a blocking function which waits until a kill signal has been received.
 It takes in `RunnerFunc`s which are nonblocking functions which set off go routines (e.g. to run an HTTP server or a gRPC server) and return a `ShutdownFunc`.
 The `ShutdownFunc`s are executed when a kill signal has been received to allow for graceful shutdown of the go routines set off by the `RunnerFunc`s.
@@ -71,8 +71,21 @@ For testing purposes you may want to run your main function, which is using `run
 
 The `CancelAll` function results in the same behaviour as sending a real kill signal to your program would, i.e.~graceful shutdown is initiated.
 
+The package level functions all share a single default scope, so `CancelAll` stops every await in the process.
+If you need isolated scopes, e.g. for tests that run in parallel, create a `Group` with `NewGroup` and use its `AwaitKillSignal` and `SimulateKillSignal` methods instead:
+	group := rununtil.NewGroup()
+	go group.AwaitKillSignal(NewRunner(logger))
+	... do your tests ...
+	group.SimulateKillSignal()
+
 The old functions `KillSignal`, `Signals` and `Killed` are still here (for backwards compatibility), but they have been deprecated.
 Please use `AwaitKillSignal` instead of `KillSignal`, `AwaitKillSignals` instead of `Signals`, and `CancelAll` instead of `Killed` (now you can just run in a go routine main and then execute `CancelAll` to finish the `AwaitKillSignal`).
+
+Shutdown order
+
+The `ShutdownFunc`s are executed one at a time in the reverse order to which their `RunnerFunc`s were registered, i.e. last in, first out, so runners should be registered in dependency order, e.g. a database before the HTTP server which uses it.
+A panic in one `ShutdownFunc` does not prevent the others from being executed; once they have all returned the first panic is propagated.
+This is a guarantee of every await unless it says otherwise, e.g. `AwaitKillSignalsInOrder` shuts down in registration order and `AwaitKillSignalsParallel` shuts down concurrently.
 */
 package rununtil
 
@@ -80,89 +93,193 @@ import (
 	"context"
 	"fmt"
 	"os"
-	"os/signal"
 	"sync"
-	"syscall"
+	"time"
 
-	"github.com/google/uuid"
 	"github.com/pkg/errors"
 )
 
-type canceller struct {
-	signals map[string]chan struct{}
-	mux     sync.Mutex
-}
-
-func (canc *canceller) addChannel(key string, c chan struct{}) {
-	canc.mux.Lock()
-	defer canc.mux.Unlock()
-	canc.signals[key] = c
-}
-
-func (canc *canceller) cancelAll() {
-	canc.mux.Lock()
-	defer canc.mux.Unlock()
-	for key := range canc.signals {
-		close(canc.signals[key])
-		delete(canc.signals, key)
-	}
-}
-
-var globalCanceller canceller
-
-func init() {
-	globalCanceller.mux.Lock()
-	globalCanceller.signals = make(map[string]chan struct{})
-	globalCanceller.mux.Unlock()
-}
-
 // ShutdownFunc is a function that should be returned by a RunnerFunc which
 // gracefully shuts down whatever is being run.
 type ShutdownFunc func()
 
 // RunnerFunc is a nonblocking function that sets off the worker go routines and
 // returns a function which can shutdown those worker go routines.
+// A runner with nothing to clean up can return a nil ShutdownFunc.
 type RunnerFunc func() ShutdownFunc
 
+// noopIfNil returns the shutdown function, or a shutdown function which does
+// nothing if it is nil, so that it is always safe to call.
+func noopIfNil(shutdown ShutdownFunc) ShutdownFunc {
+	if shutdown == nil {
+		return ShutdownFunc(func() {})
+	}
+	return shutdown
+}
+
 // AwaitKillSignal runs the provided RunnerFuncs until it receives a kill
 // signal, SIGINT or SIGTERM, at which point it executes the graceful shutdown
-// functions.
+// functions in the reverse order to which the runners were registered.
+// On Windows the kill signal is os.Interrupt instead.
 func AwaitKillSignal(runnerFuncs ...RunnerFunc) {
-	AwaitKillSignals([]os.Signal{syscall.SIGINT, syscall.SIGTERM}, runnerFuncs...)
+	AwaitKillSignals(defaultSignals(), runnerFuncs...)
 }
 
 // AwaitKillSignals runs the provided RunnerFuncs until the specified
 // signals have been recieved, at which point it executes the graceful shutdown
 // functions.
+// The shutdown functions are guaranteed to be executed in the reverse order
+// to which the runners were registered, so runners should be registered in
+// dependency order, e.g. a database before the HTTP server which uses it.
+// If a runner panics while starting up, the runners which have already
+// started are shutdown before the panic is propagated. Likewise, a panic in
+// one shutdown function does not prevent the others from being executed.
 func AwaitKillSignals(signals []os.Signal, runnerFuncs ...RunnerFunc) {
-	c := make(chan os.Signal, 1)
-	signal.Notify(c, signals...)
+	defaultGroup.AwaitKillSignals(signals, runnerFuncs...)
+}
 
-	finish := make(chan struct{})
-	uuid := uuid.New()
-	globalCanceller.addChannel(uuid.String(), finish)
+// ErrNilRunners is the value that AwaitKillSignalsSlice panics with if it is
+// given a nil slice of runners, which usually means that the code assembling
+// them never got as far as creating the slice.
+var ErrNilRunners = errors.New("awaiting with a nil slice of runners")
+
+// AwaitKillSignalsSlice is the same as AwaitKillSignals except that it takes
+// the runners as a slice, for runner lists which are assembled
+// programmatically, e.g. conditionally on the config:
+//
+//	runners := []rununtil.RunnerFunc{NewDBRunner(config)}
+//	if config.MetricsEnabled {
+//		runners = append(runners, NewMetricsRunner(config))
+//	}
+//	rununtil.AwaitKillSignalsSlice(signals, runners)
+//
+// It panics with ErrNilRunners if runnerFuncs is nil. An empty, non-nil slice
+// is treated in the same way as passing no runners to Await.
+func AwaitKillSignalsSlice(signals []os.Signal, runnerFuncs []RunnerFunc) {
+	if runnerFuncs == nil {
+		panic(ErrNilRunners)
+	}
+	Await(runnerFuncs, WithSignals(signals...))
+}
+
+// AwaitKillSignalsInOrder runs the provided RunnerFuncs until the specified
+// signals have been recieved, at which point it executes the graceful shutdown
+// functions in the same order that the runners were registered.
+// A panic in one shutdown function does not prevent the others from being
+// executed; once they have all returned the first panic is propagated.
+func AwaitKillSignalsInOrder(signals []os.Signal, runnerFuncs ...RunnerFunc) {
+	wait, release := defaultGroup.listenForKillSignal(signals)
+	defer release()
+
+	shutdowns := startRunners(runnerFuncs)
+
+	wait()
+
+	shutdownWithinDefaultTimeout(func() { shutdownInOrder(shutdowns) })
+}
+
+// AwaitKillSignalsReturn runs the provided RunnerFuncs until the specified
+// signals have been recieved, at which point it executes the graceful shutdown
+// functions in the reverse order to which the runners were registered. It
+// returns the signal which triggered the shutdown, or nil if the shutdown was
+// triggered by SimulateKillSignal or CancelAll. For example, to follow the
+// conventional exit code scheme:
+//	sig := rununtil.AwaitKillSignalsReturn(signals, NewRunner(logger))
+//	if sig, ok := sig.(syscall.Signal); ok {
+//		os.Exit(128 + int(sig))
+//	}
+func AwaitKillSignalsReturn(signals []os.Signal, runnerFuncs ...RunnerFunc) os.Signal {
+	return defaultGroup.await([]Option{WithSignals(signals...)}, runnerFuncs).Signal
+}
+
+// startRunners runs each of the RunnerFuncs and returns their ShutdownFuncs in
+// the order that the runners were registered. If a runner panics, the runners
+// that have already started are shutdown before the panic is propagated; a
+// panic in one of their shutdown functions is discarded in favour of the
+// original one.
+// Each ShutdownFunc is only executed once, however many sources of
+// cancellation fire, e.g. a real signal at the same time as
+// SimulateKillSignal.
+func startRunners(runnerFuncs []RunnerFunc) []ShutdownFunc {
+	shutdowns := make([]ShutdownFunc, 0, len(runnerFuncs))
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			func() {
+				defer func() { _ = recover() }()
+				shutdownInReverse(shutdowns)
+			}()
+			panic(recovered)
+		}
+	}()
 
 	for _, runner := range runnerFuncs {
-		shutdown := runner()
-		defer shutdown()
+		shutdowns = append(shutdowns, once(noopIfNil(runner())))
 	}
+	return shutdowns
+}
 
-	// Wait for a kill signal
-	select {
-	case <-c:
-		break
-	case <-finish:
-		break
+// once returns a ShutdownFunc which only executes the shutdown function the
+// first time it is called.
+func once(shutdown ShutdownFunc) ShutdownFunc {
+	var o sync.Once
+	return func() {
+		o.Do(shutdown)
+	}
+}
+
+// shutdownInReverse executes the shutdown functions in the reverse order to
+// which they were registered. Panics in the shutdown functions are recovered
+// so that they cannot stop the others from being executed, and the first one
+// is propagated once they have all returned.
+func shutdownInReverse(shutdowns []ShutdownFunc) {
+	shutdownInReverseWithGap(shutdowns, 0)
+}
+
+// shutdownInReverseWithGap is the same as shutdownInReverse except that it
+// pauses for gap between consecutive shutdown functions.
+func shutdownInReverseWithGap(shutdowns []ShutdownFunc, gap time.Duration) {
+	var firstPanic interface{}
+	for idx := len(shutdowns) - 1; idx >= 0; idx-- {
+		if gap > 0 && idx < len(shutdowns)-1 {
+			time.Sleep(gap)
+		}
+		callRecovering(shutdowns[idx], &firstPanic)
+	}
+	if firstPanic != nil {
+		panic(firstPanic)
+	}
+}
+
+// shutdownInOrder is the same as shutdownInReverse except that the shutdown
+// functions are executed in the order that they were registered.
+func shutdownInOrder(shutdowns []ShutdownFunc) {
+	var firstPanic interface{}
+	for _, shutdown := range shutdowns {
+		callRecovering(shutdown, &firstPanic)
+	}
+	if firstPanic != nil {
+		panic(firstPanic)
 	}
 }
 
+// callRecovering executes the shutdown function, recovering any panic and
+// recording it in firstPanic if it is the first one.
+func callRecovering(shutdown ShutdownFunc, firstPanic *interface{}) {
+	defer func() {
+		if recovered := recover(); recovered != nil && *firstPanic == nil {
+			*firstPanic = recovered
+		}
+	}()
+	shutdown()
+}
+
 // CancelAll will stop all the awaits in the same way that a kill
 // signal would stop them. To use:
 //	go main()
 //	... do your tests ...
 //	rununtil.CancelAll()
 func CancelAll() {
-	globalCanceller.cancelAll()
+	SimulateKillSignal()
 }
 
 // KillSignal runs the provided runner function until it receives a kill signal,
@@ -190,23 +307,113 @@ func Signals(runner RunnerFunc, signals ...os.Signal) {
 // Deprecated. Please just run your main function and use
 // rununtil.CancelAll.
 func Killed(main func()) context.CancelFunc {
+	cancel, _ := KilledDone(main)
+	return cancel
+}
+
+// KilledDone is the same as Killed except that it also returns a channel which
+// is closed when main returns. This allows a test to assert that main actually
+// blocked until it was cancelled, rather than relying on sleeps:
+//	kill, done := rununtil.KilledDone(main)
+//	... do some stuff, e.g. send some requests to the webserver ...
+//	kill()
+//	<-done
+//
+// New tests should prefer RunUntilReady.
+func KilledDone(main func()) (context.CancelFunc, <-chan struct{}) {
 	ctx, cancel := context.WithCancel(context.Background())
-	go runMain(ctx, main)
+	done := make(chan struct{})
+	go runMain(ctx, main, done, nil)
+
+	return cancel, done
+}
+
+// ErrMainReturned is returned by the function returned by KilledErr if main
+// had already returned before it was called, i.e. if main was not blocking on
+// an await.
+var ErrMainReturned = errors.New("main returned before it was killed")
+
+// KilledErr is the same as Killed except that the returned function waits for
+// main to return, and reports whether main was actually blocking until then.
+// It returns ErrMainReturned if main had already returned on its own, in which
+// case nothing is cancelled, or an error if main did not return in time once
+// it had been cancelled:
+//
+//	kill := rununtil.KilledErr(main)
+//	... do some stuff, e.g. send some requests to the webserver ...
+//	if err := kill(); err != nil {
+//		t.Fatal(err)
+//	}
+func KilledErr(main func()) func() error {
+	kill, _ := killedErr(main)
+	return kill
+}
+
+// killedErr is the implementation of KilledErr, which also returns the channel
+// that is closed once main has returned.
+func killedErr(main func()) (func() error, <-chan struct{}) {
+	cancel, done := KilledDone(main)
+	return func() error {
+		select {
+		case <-done:
+			cancel()
+			return ErrMainReturned
+		default:
+		}
+
+		cancel()
+		select {
+		case <-done:
+			return nil
+		case <-time.After(readyTimeout):
+			return errors.Errorf("main did not return within %v of being killed", readyTimeout)
+		}
+	}, done
+}
+
+// KilledWith is the same as Killed except that, when the returned
+// context.CancelFunc is executed, it actually sends the specified signal to the
+// process rather than calling CancelAll. This allows signal specific behaviour
+// to be tested, e.g. that a SIGHUP reloads the config rather than shutting
+// down:
+//	reload := rununtil.KilledWith(main, syscall.SIGHUP)
+//	reload()
+//
+// Note that delivering a signal which nothing is listening for will terminate
+// the test process.
+func KilledWith(main func(), sig os.Signal) context.CancelFunc {
+	ctx, cancel := context.WithCancel(context.Background())
+	go runMain(ctx, main, make(chan struct{}), sig)
 
 	return cancel
 }
 
-func runMain(ctx context.Context, main func()) {
+func runMain(ctx context.Context, main func(), done chan struct{}, sig os.Signal) {
+	defer close(done)
 	p, err := os.FindProcess(os.Getpid())
 	if err != nil {
 		fmt.Printf("ERROR: %+v\n", errors.Wrap(err, "trying to get PID"))
 	}
-	go killMainWhenDone(ctx, p)
+	go killMainWhenDone(ctx, p, sig, done)
 	main()
 }
 
-func killMainWhenDone(ctx context.Context, p *os.Process) {
-	<-ctx.Done()
+// killMainWhenDone sends the signal to the process once the context is done,
+// or calls CancelAll if there is no signal to send. Nothing is sent if main
+// has already returned, so that a main which did not block cannot interfere
+// with the awaits of later tests.
+func killMainWhenDone(ctx context.Context, p *os.Process, sig os.Signal, mainDone <-chan struct{}) {
+	select {
+	case <-ctx.Done():
+	case <-mainDone:
+		return
+	}
 
-	CancelAll()
+	if sig == nil {
+		CancelAll()
+		return
+	}
+	if err := p.Signal(sig); err != nil {
+		fmt.Printf("ERROR: %+v\n", errors.Wrapf(err, "sending %v", sig))
+	}
 }
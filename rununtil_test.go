@@ -1,7 +1,12 @@
 package rununtil_test
 
 import (
+	"context"
+	"fmt"
 	"os"
+	"os/signal"
+	"reflect"
+	"sync/atomic"
 	"syscall"
 	"testing"
 	"time"
@@ -17,6 +22,23 @@ func helperSendSignal(t *testing.T, p *os.Process, sent *bool, signal os.Signal,
 	*sent = true
 }
 
+// helperSignalOnceListening sends signal to p once an await on the default
+// Group has started listening for it, rather than after a fixed delay, so
+// that the signal can't arrive before the await is ready for it.
+func helperSignalOnceListening(t *testing.T, p *os.Process, signal os.Signal) {
+	deadline := time.Now().Add(time.Second)
+	for rununtil.ActiveCount() == 0 {
+		if time.Now().After(deadline) {
+			t.Errorf("expected an await to have started listening for %v", signal)
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if err := p.Signal(signal); err != nil {
+		t.Errorf("unexpected error occurred: %v", err)
+	}
+}
+
 func helperMakeFakeRunner(hasBeenShutdown *bool) rununtil.RunnerFunc {
 	return rununtil.RunnerFunc(func() rununtil.ShutdownFunc {
 		return rununtil.ShutdownFunc(func() {
@@ -25,12 +47,40 @@ func helperMakeFakeRunner(hasBeenShutdown *bool) rununtil.RunnerFunc {
 	})
 }
 
+// helperMakeCancellingRunner returns a runner which calls CancelAll as soon as
+// it has been started, deterministically ending the await it was passed to.
+func helperMakeCancellingRunner() rununtil.RunnerFunc {
+	return rununtil.RunnerFunc(func() rununtil.ShutdownFunc {
+		rununtil.CancelAll()
+		return rununtil.ShutdownFunc(func() {})
+	})
+}
+
 func helperMakeMain(hasBeenKilled *bool) func() {
 	return func() {
 		rununtil.AwaitKillSignal(helperMakeFakeRunner(hasBeenKilled))
 	}
 }
 
+// helperMakeReadyMain returns a main, which is using rununtil.AwaitKillSignal,
+// and a ready function which reports whether its runner has been started.
+func helperMakeReadyMain(hasBeenKilled *bool) (main func(), ready func() bool) {
+	var started int32
+	main = func() {
+		rununtil.AwaitKillSignal(
+			helperMakeFakeRunner(hasBeenKilled),
+			rununtil.RunnerFunc(func() rununtil.ShutdownFunc {
+				atomic.StoreInt32(&started, 1)
+				return nil
+			}),
+		)
+	}
+	ready = func() bool {
+		return atomic.LoadInt32(&started) == 1
+	}
+	return main, ready
+}
+
 func TestRununtilAwaitKillSignal(t *testing.T) {
 	table := []struct {
 		name   string
@@ -97,6 +147,83 @@ func TestRununtilAwaitKillSignal_MultipleRunnerFuncs(t *testing.T) {
 	}
 }
 
+func helperMakeOrderedRunner(idx int, order *[]int) rununtil.RunnerFunc {
+	return rununtil.RunnerFunc(func() rununtil.ShutdownFunc {
+		return rununtil.ShutdownFunc(func() {
+			*order = append(*order, idx)
+		})
+	})
+}
+
+func TestRununtilAwaitKillSignals_ReverseOrder(t *testing.T) {
+	var order []int
+	rununtil.AwaitKillSignals(
+		[]os.Signal{syscall.SIGINT},
+		helperMakeOrderedRunner(1, &order),
+		helperMakeOrderedRunner(2, &order),
+		helperMakeOrderedRunner(3, &order),
+		helperMakeCancellingRunner(),
+	)
+
+	expected := []int{3, 2, 1}
+	if !reflect.DeepEqual(order, expected) {
+		t.Fatalf("expected shutdown order %v, got: %v", expected, order)
+	}
+}
+
+func TestRununtilAwaitKillSignalsInOrder(t *testing.T) {
+	var order []int
+	rununtil.AwaitKillSignalsInOrder(
+		[]os.Signal{syscall.SIGINT},
+		helperMakeOrderedRunner(1, &order),
+		helperMakeOrderedRunner(2, &order),
+		helperMakeOrderedRunner(3, &order),
+		helperMakeCancellingRunner(),
+	)
+
+	expected := []int{1, 2, 3}
+	if !reflect.DeepEqual(order, expected) {
+		t.Fatalf("expected shutdown order %v, got: %v", expected, order)
+	}
+}
+
+func TestRununtilAwaitKillSignalsReturn(t *testing.T) {
+	var hasBeenShutdown bool
+	p, err := os.FindProcess(os.Getpid())
+	if err != nil {
+		t.Fatalf("Unexpected error when finding process: %v", err)
+	}
+
+	go helperSignalOnceListening(t, p, syscall.SIGTERM)
+	sig := rununtil.AwaitKillSignalsReturn(
+		[]os.Signal{syscall.SIGINT, syscall.SIGTERM},
+		helperMakeFakeRunner(&hasBeenShutdown),
+	)
+
+	if sig != syscall.SIGTERM {
+		t.Fatalf("expected SIGTERM to be returned, got: %v", sig)
+	}
+	if !hasBeenShutdown {
+		t.Fatal("expected the shutdown function to have been called")
+	}
+}
+
+func TestRununtilAwaitKillSignalsReturn_Simulated(t *testing.T) {
+	var hasBeenShutdown bool
+	sig := rununtil.AwaitKillSignalsReturn(
+		[]os.Signal{syscall.SIGINT, syscall.SIGTERM},
+		helperMakeFakeRunner(&hasBeenShutdown),
+		helperMakeCancellingRunner(),
+	)
+
+	if sig != nil {
+		t.Fatalf("expected nil to be returned, got: %v", sig)
+	}
+	if !hasBeenShutdown {
+		t.Fatal("expected the shutdown function to have been called")
+	}
+}
+
 func TestRununtilKilled(t *testing.T) {
 	var hasBeenKilled bool
 	cancel := rununtil.Killed(helperMakeMain(&hasBeenKilled))
@@ -109,37 +236,77 @@ func TestRununtilKilled(t *testing.T) {
 	}
 }
 
-func TestRununtilCancelAll(t *testing.T) {
+func TestRununtilKilledDone(t *testing.T) {
+	started := make(chan struct{})
 	var hasBeenKilled bool
-	rununtil.Killed(helperMakeMain(&hasBeenKilled))
+	kill, done := rununtil.KilledDone(func() {
+		rununtil.AwaitKillSignal(helperMakeStartedRunner(started, &hasBeenKilled))
+	})
+	<-started
 
-	// yield control back to scheduler so that the go routines can actually
-	// start
-	time.Sleep(time.Millisecond)
+	select {
+	case <-done:
+		t.Fatal("expected main to block until it was killed")
+	default:
+	}
+
+	kill()
+	<-done
+	if !hasBeenKilled {
+		t.Fatal("expected main to have been killed")
+	}
+}
+
+func TestRununtilKilledWith(t *testing.T) {
+	started := make(chan struct{})
+	shutdown := make(chan os.Signal, 1)
+	hooks := rununtil.Hooks{
+		OnSignal: func(sig os.Signal) {
+			shutdown <- sig
+		},
+	}
+	var hasBeenKilled bool
+	kill := rununtil.KilledWith(func() {
+		rununtil.Await(
+			[]rununtil.RunnerFunc{helperMakeStartedRunner(started, &hasBeenKilled)},
+			rununtil.WithSignals(syscall.SIGTERM),
+			rununtil.WithHooks(hooks),
+		)
+	}, syscall.SIGTERM)
+	<-started
+
+	kill()
+
+	select {
+	case sig := <-shutdown:
+		if sig != syscall.SIGTERM {
+			t.Fatalf("expected main to have been killed by SIGTERM, got: %v", sig)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected main to have been killed")
+	}
+}
+
+func TestRununtilCancelAll(t *testing.T) {
+	var hasBeenKilled bool
+	main, ready := helperMakeReadyMain(&hasBeenKilled)
+	waitForMain := rununtil.RunUntilReady(t, main, ready)
 
 	rununtil.CancelAll()
 
-	// yield control back to scheduler so that killing can actually happen
-	time.Sleep(time.Millisecond)
+	waitForMain()
 	if !hasBeenKilled {
 		t.Fatal("expected main to have been killed")
 	}
 }
 
 func TestRununtilCancelAll_MultipleTimes(t *testing.T) {
-	var hasBeenKilled bool
 	for idx := 0; idx < 100; idx++ {
-		hasBeenKilled = false
-		rununtil.Killed(helperMakeMain(&hasBeenKilled))
-
-		// yield control back to scheduler so that the go routines can actually
-		// start
-		time.Sleep(time.Millisecond)
-
-		rununtil.CancelAll()
+		var hasBeenKilled bool
+		main, ready := helperMakeReadyMain(&hasBeenKilled)
+		cancel := rununtil.RunUntilReady(t, main, ready)
+		cancel()
 
-		// yield control back to scheduler so that killing can actually happen
-		time.Sleep(time.Millisecond)
 		if !hasBeenKilled {
 			t.Fatal("expected main to have been killed")
 		}
@@ -148,13 +315,19 @@ func TestRununtilCancelAll_MultipleTimes(t *testing.T) {
 
 func TestRununtilCancelAll_Threadsafe(t *testing.T) {
 	var hasBeenKilledVec [100]bool
-	for idx := 0; idx < 100; idx++ {
-		cancel := rununtil.Killed(helperMakeMain(&hasBeenKilledVec[idx]))
+	cancels := make([]func(), 0, len(hasBeenKilledVec))
+	for idx := range hasBeenKilledVec {
+		main, ready := helperMakeReadyMain(&hasBeenKilledVec[idx])
+		cancels = append(cancels, rununtil.RunUntilReady(t, main, ready))
+	}
+
+	for range cancels {
+		go rununtil.CancelAll()
+	}
+	for _, cancel := range cancels {
 		cancel()
-		rununtil.CancelAll()
 	}
-	// yield control back to scheduler so that killing can actually happen
-	time.Sleep(time.Millisecond)
+
 	for idx, hasBeenKilled := range hasBeenKilledVec {
 		if !hasBeenKilled {
 			t.Fatalf("expected main to have been killed: %d", idx)
@@ -162,11 +335,330 @@ func TestRununtilCancelAll_Threadsafe(t *testing.T) {
 	}
 }
 
-// Annoyingly this test has to be run by itself to actually fail...
-//	go test -v -run TestKilled_FailsForNonblockingMain
-// Fixed test by not actually sending a kill signal anymore --
-// it now calls rununtil.CancelAll().
+func TestRununtilCancelAll_FreshAwaitNotCancelled(t *testing.T) {
+	for idx := 0; idx < 100; idx++ {
+		rununtil.CancelAll()
+
+		// an await started after CancelAll has returned is not cancelled by it
+		var hasBeenKilled bool
+		main, ready := helperMakeReadyMain(&hasBeenKilled)
+		cancel := rununtil.RunUntilReady(t, main, ready)
+		if count := rununtil.ActiveCount(); count != 1 {
+			t.Fatalf("expected the fresh await to still be running, got %d active: %d", count, idx)
+		}
+		cancel()
+
+		if !hasBeenKilled {
+			t.Fatalf("expected main to have been killed: %d", idx)
+		}
+	}
+}
+
+func TestRununtilAwaitKillSignals_SignalAndSimulateTogether(t *testing.T) {
+	// catch the signal ourselves too, in case the await has already returned
+	// by the time it arrives
+	caught := make(chan os.Signal, 1)
+	signal.Notify(caught, syscall.SIGINT)
+	defer signal.Stop(caught)
+
+	p, err := os.FindProcess(os.Getpid())
+	if err != nil {
+		t.Fatalf("Unexpected error when finding process: %v", err)
+	}
+	var shutdownCalls int32
+	countingRunner := rununtil.RunnerFunc(func() rununtil.ShutdownFunc {
+		return rununtil.ShutdownFunc(func() {
+			atomic.AddInt32(&shutdownCalls, 1)
+		})
+	})
+	firingRunner := rununtil.RunnerFunc(func() rununtil.ShutdownFunc {
+		fire := make(chan struct{})
+		go func() {
+			<-fire
+			if err := p.Signal(syscall.SIGINT); err != nil {
+				t.Errorf("unexpected error occurred: %v", err)
+			}
+		}()
+		go func() {
+			<-fire
+			rununtil.SimulateKillSignal()
+		}()
+		close(fire)
+		return nil
+	})
+
+	rununtil.AwaitKillSignals([]os.Signal{syscall.SIGINT}, countingRunner, firingRunner)
+
+	select {
+	case <-caught:
+	case <-time.After(time.Second):
+		t.Fatal("expected the signal to have been delivered")
+	}
+	if calls := atomic.LoadInt32(&shutdownCalls); calls != 1 {
+		t.Fatalf("expected the shutdown function to be called exactly once, got: %d", calls)
+	}
+}
+
 func TestKilled_FailsForNonblockingMain(t *testing.T) {
-	cancel := rununtil.Killed(func() {})
+	cancel, done := rununtil.KilledDone(func() {})
+
+	// main returns without being killed, which the done channel makes visible,
+	// and cancelling it afterwards does nothing
+	<-done
 	cancel()
 }
+
+func TestRununtilKilledErr(t *testing.T) {
+	var hasBeenKilled bool
+	started := make(chan struct{})
+	kill := rununtil.KilledErr(func() {
+		rununtil.AwaitKillSignal(helperMakeStartedRunner(started, &hasBeenKilled))
+	})
+	<-started
+
+	if err := kill(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !hasBeenKilled {
+		t.Fatal("expected main to have been killed")
+	}
+}
+
+func TestRununtilKilledErr_NonblockingMain(t *testing.T) {
+	kill, done := rununtil.KilledErrDone(func() {})
+	<-done
+
+	if err := kill(); err != rununtil.ErrMainReturned {
+		t.Fatalf("expected ErrMainReturned, got: %v", err)
+	}
+}
+
+func TestRununtilAwaitKillSignals_NilShutdownFunc(t *testing.T) {
+	nilRunner := rununtil.RunnerFunc(func() rununtil.ShutdownFunc {
+		return nil
+	})
+	table := []struct {
+		name  string
+		await func(runnerFuncs ...rununtil.RunnerFunc)
+	}{
+		{
+			name: "AwaitKillSignals",
+			await: func(runnerFuncs ...rununtil.RunnerFunc) {
+				rununtil.AwaitKillSignals([]os.Signal{syscall.SIGINT}, runnerFuncs...)
+			},
+		},
+		{
+			name: "AwaitKillSignalsInOrder",
+			await: func(runnerFuncs ...rununtil.RunnerFunc) {
+				rununtil.AwaitKillSignalsInOrder([]os.Signal{syscall.SIGINT}, runnerFuncs...)
+			},
+		},
+		{
+			name: "AwaitKillSignalsParallel",
+			await: func(runnerFuncs ...rununtil.RunnerFunc) {
+				rununtil.AwaitKillSignalsParallel([]os.Signal{syscall.SIGINT}, runnerFuncs...)
+			},
+		},
+	}
+	for _, test := range table {
+		t.Run(test.name, func(t *testing.T) {
+			var hasBeenShutdown bool
+			test.await(nilRunner, helperMakeFakeRunner(&hasBeenShutdown), helperMakeCancellingRunner())
+			if !hasBeenShutdown {
+				t.Fatal("expected the other shutdown function to have been called")
+			}
+		})
+	}
+}
+
+func TestRununtilAwaitKillSignals_PanickingShutdownFunc(t *testing.T) {
+	var order []int
+	panickingRunner := rununtil.RunnerFunc(func() rununtil.ShutdownFunc {
+		return rununtil.ShutdownFunc(func() {
+			panic("shutdown failed")
+		})
+	})
+
+	defer func() {
+		if recovered := recover(); recovered != "shutdown failed" {
+			t.Fatalf("expected the shutdown panic to have been propagated, got: %v", recovered)
+		}
+		expected := []int{2, 1}
+		if !reflect.DeepEqual(order, expected) {
+			t.Fatalf("expected the other shutdown functions to have been called in order %v, got: %v", expected, order)
+		}
+	}()
+
+	rununtil.AwaitKillSignals(
+		[]os.Signal{syscall.SIGINT},
+		helperMakeOrderedRunner(1, &order),
+		helperMakeOrderedRunner(2, &order),
+		panickingRunner,
+		helperMakeCancellingRunner(),
+	)
+}
+
+func TestRununtilAwaitKillSignalsSlice(t *testing.T) {
+	var hasBeenShutdown bool
+	runners := []rununtil.RunnerFunc{helperMakeFakeRunner(&hasBeenShutdown)}
+	runners = append(runners, helperMakeCancellingRunner())
+
+	rununtil.AwaitKillSignalsSlice([]os.Signal{syscall.SIGINT}, runners)
+
+	if !hasBeenShutdown {
+		t.Fatal("expected the shutdown function to have been called")
+	}
+}
+
+func TestRununtilAwaitKillSignalsSlice_Nil(t *testing.T) {
+	defer func() {
+		if recovered := recover(); recovered != rununtil.ErrNilRunners {
+			t.Fatalf("expected a panic with ErrNilRunners, got: %v", recovered)
+		}
+	}()
+
+	rununtil.AwaitKillSignalsSlice([]os.Signal{syscall.SIGINT}, nil)
+}
+
+func TestRununtil_ShutdownOrderIsLIFO(t *testing.T) {
+	awaits := []struct {
+		name  string
+		await func(runnerFuncs ...rununtil.RunnerFunc)
+	}{
+		{
+			name: "AwaitKillSignals",
+			await: func(runnerFuncs ...rununtil.RunnerFunc) {
+				rununtil.AwaitKillSignals([]os.Signal{syscall.SIGINT}, runnerFuncs...)
+			},
+		},
+		{
+			name: "AwaitKillSignalsWithNotifier",
+			await: func(runnerFuncs ...rununtil.RunnerFunc) {
+				rununtil.AwaitKillSignalsWithNotifier(func(c chan<- os.Signal, sig ...os.Signal) {}, []os.Signal{syscall.SIGINT}, runnerFuncs...)
+			},
+		},
+		{
+			name: "AwaitKillSignalWithReload",
+			await: func(runnerFuncs ...rununtil.RunnerFunc) {
+				rununtil.AwaitKillSignalWithReload(func() {}, runnerFuncs...)
+			},
+		},
+		{
+			name: "AwaitKillSignalsCtx",
+			await: func(runnerFuncs ...rununtil.RunnerFunc) {
+				ctxRunners := make([]rununtil.RunnerFuncCtx, 0, len(runnerFuncs))
+				for _, runner := range runnerFuncs {
+					runner := runner
+					ctxRunners = append(ctxRunners, func(ctx context.Context) rununtil.ShutdownFunc {
+						return runner()
+					})
+				}
+				rununtil.AwaitKillSignalsCtx([]os.Signal{syscall.SIGINT}, ctxRunners...)
+			},
+		},
+	}
+	counts := []int{1, 2, 5}
+
+	for _, await := range awaits {
+		for _, count := range counts {
+			t.Run(fmt.Sprintf("%s/%d", await.name, count), func(t *testing.T) {
+				runner, order := rununtil.RecordShutdownOrder()
+				runners := make([]rununtil.RunnerFunc, 0, count+1)
+				expected := make([]int, 0, count)
+				for idx := 0; idx < count; idx++ {
+					runners = append(runners, runner(idx))
+					expected = append([]int{idx}, expected...)
+				}
+				runners = append(runners, helperMakeCancellingRunner())
+
+				await.await(runners...)
+
+				if !reflect.DeepEqual(*order, expected) {
+					t.Fatalf("expected shutdown order %v, got: %v", expected, *order)
+				}
+			})
+		}
+	}
+}
+
+func TestRununtil_ShutdownPanicDoesNotSkipOthers(t *testing.T) {
+	awaits := []struct {
+		name     string
+		await    func(runnerFuncs ...rununtil.RunnerFunc)
+		expected []int
+	}{
+		{
+			name: "AwaitKillSignalsInOrder",
+			await: func(runnerFuncs ...rununtil.RunnerFunc) {
+				rununtil.AwaitKillSignalsInOrder([]os.Signal{syscall.SIGINT}, runnerFuncs...)
+			},
+			expected: []int{0, 1},
+		},
+		{
+			name: "AwaitKillSignalsWithWatchdog",
+			await: func(runnerFuncs ...rununtil.RunnerFunc) {
+				rununtil.AwaitKillSignalsWithWatchdog([]os.Signal{syscall.SIGINT}, time.Second, runnerFuncs...)
+			},
+			expected: []int{1, 0},
+		},
+		{
+			name: "AwaitKillSignalsWithShutdownContext",
+			await: func(runnerFuncs ...rununtil.RunnerFunc) {
+				ctxRunners := make([]rununtil.RunnerFuncShutdownCtx, 0, len(runnerFuncs))
+				for _, runner := range runnerFuncs {
+					runner := runner
+					ctxRunners = append(ctxRunners, func() rununtil.ShutdownFuncCtx {
+						shutdown := runner()
+						return func(ctx context.Context) { shutdown() }
+					})
+				}
+				rununtil.AwaitKillSignalsWithShutdownContext([]os.Signal{syscall.SIGINT}, time.Second, ctxRunners...)
+			},
+			expected: []int{1, 0},
+		},
+	}
+	panickingRunner := rununtil.RunnerFunc(func() rununtil.ShutdownFunc {
+		return func() { panic("failed to shutdown") }
+	})
+
+	for _, await := range awaits {
+		t.Run(await.name, func(t *testing.T) {
+			runner, order := rununtil.RecordShutdownOrder()
+			func() {
+				defer func() {
+					if recovered := recover(); recovered != "failed to shutdown" {
+						t.Fatalf("expected the panic to be propagated, got: %v", recovered)
+					}
+				}()
+				await.await(runner(0), panickingRunner, runner(1), helperMakeCancellingRunner())
+			}()
+
+			if !reflect.DeepEqual(*order, await.expected) {
+				t.Fatalf("expected shutdown order %v, got: %v", await.expected, *order)
+			}
+		})
+	}
+}
+
+func TestRununtilAwaitKillSignalsWithShutdownContext_StartupPanic(t *testing.T) {
+	var hasBeenShutdown bool
+	startedRunner := rununtil.RunnerFuncShutdownCtx(func() rununtil.ShutdownFuncCtx {
+		return func(ctx context.Context) { hasBeenShutdown = true }
+	})
+	panickingRunner := rununtil.RunnerFuncShutdownCtx(func() rununtil.ShutdownFuncCtx {
+		panic("failed to start")
+	})
+
+	func() {
+		defer func() {
+			if recovered := recover(); recovered != "failed to start" {
+				t.Fatalf("expected the panic to be propagated, got: %v", recovered)
+			}
+		}()
+		rununtil.AwaitKillSignalsWithShutdownContext([]os.Signal{syscall.SIGINT}, time.Second, startedRunner, panickingRunner)
+	}()
+
+	if !hasBeenShutdown {
+		t.Fatal("expected the runner which had already started to have been shutdown")
+	}
+}
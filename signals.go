@@ -0,0 +1,135 @@
+package rununtil
+
+import (
+	"os"
+	"os/signal"
+	"sort"
+)
+
+// signalStop stops relaying signals to the channel. It is a variable so that
+// it can be stubbed in tests.
+var signalStop = signal.Stop
+
+// signalListener is the channel of a running await along with the signals
+// that it is currently notified of.
+type signalListener struct {
+	notify  NotifyFunc
+	c       chan os.Signal
+	signals []os.Signal
+}
+
+// addListener starts notifying c of the signals and records it against the
+// Group, so that its signals can be adjusted while the await is running.
+func (g *Group) addListener(notify NotifyFunc, c chan os.Signal, signals []os.Signal) *signalListener {
+	g.listenersMux.Lock()
+	defer g.listenersMux.Unlock()
+
+	listener := &signalListener{notify: notify, c: c, signals: append([]os.Signal{}, signals...)}
+	notify(c, signals...)
+	g.listeners[listener] = struct{}{}
+	return listener
+}
+
+// removeListener stops notifying the listener's channel of any signals, so
+// that the runtime doesn't keep delivering signals to an abandoned channel,
+// and forgets it.
+func (g *Group) removeListener(listener *signalListener) {
+	g.listenersMux.Lock()
+	defer g.listenersMux.Unlock()
+	signalStop(listener.c)
+	delete(g.listeners, listener)
+}
+
+// AddSignal makes the awaits which are currently running on the Group also
+// treat sig as a kill signal, e.g. to start handling SIGHUP based on config
+// that was loaded after startup. Awaits which are started afterwards are not
+// affected.
+func (g *Group) AddSignal(sig os.Signal) {
+	g.listenersMux.Lock()
+	defer g.listenersMux.Unlock()
+
+	for listener := range g.listeners {
+		if !containsSignal(listener.signals, sig) {
+			listener.signals = append(listener.signals, sig)
+			listener.notify(listener.c, sig)
+		}
+	}
+}
+
+// RemoveSignal stops the awaits which are currently running on the Group from
+// treating sig as a kill signal. This requires re-notifying their channels of
+// the remaining signals. Note that once nothing in the process is listening
+// for a signal, Go restores its default disposition, so removing one of the
+// default kill signals, e.g. SIGTERM, means that receiving it will terminate
+// the process without a graceful shutdown.
+func (g *Group) RemoveSignal(sig os.Signal) {
+	g.listenersMux.Lock()
+	defer g.listenersMux.Unlock()
+
+	for listener := range g.listeners {
+		if !containsSignal(listener.signals, sig) {
+			continue
+		}
+		remaining := make([]os.Signal, 0, len(listener.signals))
+		for _, s := range listener.signals {
+			if s != sig {
+				remaining = append(remaining, s)
+			}
+		}
+		listener.signals = remaining
+
+		signalStop(listener.c)
+		// notifying of no signals would mean notifying of all of them
+		if len(remaining) > 0 {
+			listener.notify(listener.c, remaining...)
+		}
+	}
+}
+
+// Signals returns the signals which the awaits that are currently running on
+// the Group are notified of, including those added with AddSignal, sorted by
+// name. It is intended for debugging, e.g. to find out why a SIGHUP isn't
+// doing anything.
+func (g *Group) Signals() []os.Signal {
+	g.listenersMux.Lock()
+	defer g.listenersMux.Unlock()
+
+	var signals []os.Signal
+	for listener := range g.listeners {
+		for _, sig := range listener.signals {
+			if !containsSignal(signals, sig) {
+				signals = append(signals, sig)
+			}
+		}
+	}
+	sort.Slice(signals, func(i, j int) bool {
+		return signals[i].String() < signals[j].String()
+	})
+	return signals
+}
+
+// SignalName returns a stable name for the signal, e.g. "SIGTERM", which is the
+// same on every platform, unlike the String of the os.Signal, so that log lines
+// and metrics labels are consistent. Signals other than SIGINT, SIGTERM,
+// SIGHUP, SIGQUIT, SIGUSR1 and SIGUSR2 fall back to their String, and a nil
+// signal, e.g. the Signal of a Result which was not triggered by a signal, is
+// named "".
+func SignalName(sig os.Signal) string {
+	if sig == nil {
+		return ""
+	}
+	if name, ok := signalNames[sig]; ok {
+		return name
+	}
+	return sig.String()
+}
+
+// containsSignal reports whether sig is one of the signals.
+func containsSignal(signals []os.Signal, sig os.Signal) bool {
+	for _, s := range signals {
+		if s == sig {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,106 @@
+package rununtil_test
+
+import (
+	"os"
+	"reflect"
+	"syscall"
+	"testing"
+
+	"github.com/mec07/rununtil"
+)
+
+func TestGroupAddSignal(t *testing.T) {
+	p, err := os.FindProcess(os.Getpid())
+	if err != nil {
+		t.Fatalf("Unexpected error when finding process: %v", err)
+	}
+	group := rununtil.NewGroup()
+	var received os.Signal
+	hooks := rununtil.Hooks{
+		OnSignal: func(sig os.Signal) {
+			received = sig
+		},
+	}
+	addingRunner := rununtil.RunnerFunc(func() rununtil.ShutdownFunc {
+		group.AddSignal(syscall.SIGHUP)
+		if err := p.Signal(syscall.SIGHUP); err != nil {
+			t.Errorf("unexpected error occurred: %v", err)
+		}
+		return nil
+	})
+
+	group.Await(
+		[]rununtil.RunnerFunc{addingRunner},
+		rununtil.WithSignals(syscall.SIGINT),
+		rununtil.WithHooks(hooks),
+	)
+
+	if received != syscall.SIGHUP {
+		t.Fatalf("expected the added signal to have stopped the await, got: %v", received)
+	}
+}
+
+func TestGroupRemoveSignal(t *testing.T) {
+	group := rununtil.NewGroup()
+	var notified [][]os.Signal
+	notify := func(c chan<- os.Signal, sig ...os.Signal) {
+		notified = append(notified, sig)
+	}
+	_, release := rununtil.ListenForKillSignalWithNotifier(group, notify, []os.Signal{syscall.SIGINT, syscall.SIGTERM})
+	defer release()
+
+	group.RemoveSignal(syscall.SIGTERM)
+	group.RemoveSignal(syscall.SIGTERM)
+
+	expected := [][]os.Signal{
+		{syscall.SIGINT, syscall.SIGTERM},
+		{syscall.SIGINT},
+	}
+	if !reflect.DeepEqual(notified, expected) {
+		t.Fatalf("expected the channel to have been notified of %v, got: %v", expected, notified)
+	}
+}
+
+func TestGroupAwait_StopsSignals(t *testing.T) {
+	var stopped []chan<- os.Signal
+	restore := rununtil.SetSignalStop(func(c chan<- os.Signal) {
+		stopped = append(stopped, c)
+	})
+	defer restore()
+
+	group := rununtil.NewGroup()
+	var notifier fakeNotifier
+	wait, release := rununtil.ListenForKillSignalWithNotifier(group, notifier.notify, []os.Signal{syscall.SIGTERM})
+
+	notifier.c <- syscall.SIGTERM
+	wait()
+	if len(stopped) != 0 {
+		t.Fatal("did not expect the channel to be detached before the await returns")
+	}
+	release()
+
+	if len(stopped) != 1 || stopped[0] != notifier.c {
+		t.Fatalf("expected the channel to have been detached from the signals, got: %v", stopped)
+	}
+}
+
+func TestGroupSignals(t *testing.T) {
+	group := rununtil.NewGroup()
+	notify := func(c chan<- os.Signal, sig ...os.Signal) {}
+	_, releaseA := rununtil.ListenForKillSignalWithNotifier(group, notify, []os.Signal{syscall.SIGTERM, syscall.SIGINT})
+	_, releaseB := rununtil.ListenForKillSignalWithNotifier(group, notify, []os.Signal{syscall.SIGINT})
+
+	group.AddSignal(syscall.SIGHUP)
+	group.RemoveSignal(syscall.SIGTERM)
+
+	expected := []os.Signal{syscall.SIGHUP, syscall.SIGINT}
+	if signals := group.Signals(); !reflect.DeepEqual(signals, expected) {
+		t.Fatalf("expected signals %v, got: %v", expected, signals)
+	}
+
+	releaseA()
+	releaseB()
+	if signals := group.Signals(); len(signals) != 0 {
+		t.Fatalf("expected no signals once the awaits have returned, got: %v", signals)
+	}
+}
@@ -0,0 +1,25 @@
+//go:build !windows
+// +build !windows
+
+package rununtil
+
+import (
+	"os"
+	"syscall"
+)
+
+// defaultSignals returns the signals which are treated as kill signals by
+// AwaitKillSignal and friends, i.e. SIGINT and SIGTERM.
+func defaultSignals() []os.Signal {
+	return []os.Signal{syscall.SIGINT, syscall.SIGTERM}
+}
+
+// signalNames holds the stable names of the common signals, see SignalName.
+var signalNames = map[os.Signal]string{
+	syscall.SIGINT:  "SIGINT",
+	syscall.SIGTERM: "SIGTERM",
+	syscall.SIGHUP:  "SIGHUP",
+	syscall.SIGQUIT: "SIGQUIT",
+	syscall.SIGUSR1: "SIGUSR1",
+	syscall.SIGUSR2: "SIGUSR2",
+}
@@ -0,0 +1,42 @@
+//go:build !windows
+// +build !windows
+
+package rununtil_test
+
+import (
+	"os"
+	"reflect"
+	"syscall"
+	"testing"
+
+	"github.com/mec07/rununtil"
+)
+
+func TestDefaultSignals(t *testing.T) {
+	expected := []os.Signal{syscall.SIGINT, syscall.SIGTERM}
+	if signals := rununtil.DefaultSignals(); !reflect.DeepEqual(signals, expected) {
+		t.Fatalf("expected the default signals to be %v, got: %v", expected, signals)
+	}
+}
+
+func TestSignalName(t *testing.T) {
+	table := []struct {
+		sig      os.Signal
+		expected string
+	}{
+		{sig: os.Interrupt, expected: "SIGINT"},
+		{sig: syscall.SIGTERM, expected: "SIGTERM"},
+		{sig: syscall.SIGHUP, expected: "SIGHUP"},
+		{sig: syscall.SIGQUIT, expected: "SIGQUIT"},
+		{sig: syscall.SIGUSR1, expected: "SIGUSR1"},
+		{sig: syscall.SIGUSR2, expected: "SIGUSR2"},
+		{sig: syscall.SIGWINCH, expected: syscall.SIGWINCH.String()},
+		{sig: nil, expected: ""},
+	}
+
+	for _, test := range table {
+		if name := rununtil.SignalName(test.sig); name != test.expected {
+			t.Fatalf("expected the name of %v to be %q, got: %q", test.sig, test.expected, name)
+		}
+	}
+}
@@ -0,0 +1,25 @@
+//go:build windows
+// +build windows
+
+package rununtil
+
+import (
+	"os"
+	"syscall"
+)
+
+// defaultSignals returns the signals which are treated as kill signals by
+// AwaitKillSignal and friends. On Windows SIGTERM is never actually
+// delivered, so os.Interrupt is the only practical kill signal.
+func defaultSignals() []os.Signal {
+	return []os.Signal{os.Interrupt}
+}
+
+// signalNames holds the stable names of the common signals, see SignalName.
+// There is no SIGUSR1 or SIGUSR2 on Windows.
+var signalNames = map[os.Signal]string{
+	syscall.SIGINT:  "SIGINT",
+	syscall.SIGTERM: "SIGTERM",
+	syscall.SIGHUP:  "SIGHUP",
+	syscall.SIGQUIT: "SIGQUIT",
+}
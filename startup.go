@@ -0,0 +1,123 @@
+package rununtil
+
+import (
+	"os"
+	"os/signal"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// RunnerFuncErr is a nonblocking function that sets off the worker go routines
+// and returns a function which can shutdown those worker go routines. It
+// returns an error if the worker go routines could not be started, e.g.
+// because the port that a server is meant to listen on is already in use.
+type RunnerFuncErr func() (ShutdownFunc, error)
+
+// AwaitKillSignalErr runs the provided RunnerFuncErrs until it receives a kill
+// signal, SIGINT or SIGTERM, at which point it executes the graceful shutdown
+// functions. If any of the runners fail to start, the runners which have
+// already started are shutdown and the startup error is returned immediately.
+func AwaitKillSignalErr(runnerFuncs ...RunnerFuncErr) error {
+	return AwaitKillSignalsErr(defaultSignals(), runnerFuncs...)
+}
+
+// AwaitKillSignalsErr runs the provided RunnerFuncErrs until the specified
+// signals have been recieved, at which point it executes the graceful shutdown
+// functions. If any of the runners fail to start, the runners which have
+// already started are shutdown, in reverse order, and the startup error is
+// returned immediately without waiting for a signal.
+func AwaitKillSignalsErr(signals []os.Signal, runnerFuncs ...RunnerFuncErr) error {
+	wait, release := defaultGroup.listenForKillSignal(signals)
+	defer release()
+
+	shutdowns, err := startRunnersErr(runnerFuncs)
+	if err != nil {
+		return err
+	}
+
+	wait()
+
+	shutdownWithinDefaultTimeout(func() { shutdownInReverse(shutdowns) })
+	return nil
+}
+
+// StartErr runs the provided RunnerFuncErrs and returns straight away, leaving
+// a go routine to await a kill signal, SIGINT or SIGTERM, SimulateKillSignal
+// or CancelAll, or for the returned stop function to be called, at which point
+// it executes the graceful shutdown functions in the reverse order to which the
+// runners were registered. The returned channel receives the startup error, if
+// any of the runners fail to start, and is closed once the shutdown has
+// completed, so main can check that everything started before blocking:
+//
+//	errs, stop := rununtil.StartErr(NewRunner(logger))
+//	defer stop()
+//	if err := <-errs; err != nil {
+//		log.Fatal().Err(err).Msg("failed to start")
+//	}
+//
+// As with AwaitKillSignalsErr, the runners which have already started are
+// shutdown if a later one fails to start. The stop function does not wait for
+// the shutdown to complete and it is safe to call more than once.
+func StartErr(runnerFuncs ...RunnerFuncErr) (<-chan error, func()) {
+	errs := make(chan error, 1)
+	stop := make(chan struct{})
+	var stopOnce sync.Once
+	stopFunc := func() {
+		stopOnce.Do(func() {
+			close(stop)
+		})
+	}
+
+	c, finish, release := defaultGroup.killSignalChannels(signal.Notify, defaultSignals())
+
+	shutdowns, err := startRunnersErr(runnerFuncs)
+	if err != nil {
+		release()
+		errs <- err
+		close(errs)
+		return errs, stopFunc
+	}
+
+	go func() {
+		defer close(errs)
+		defer release()
+
+		select {
+		case <-c:
+			defaultGroup.initiateShutdown()
+		case <-finish:
+			defaultGroup.initiateShutdown()
+		case <-stop:
+		}
+
+		shutdownWithinDefaultTimeout(func() { shutdownInReverse(shutdowns) })
+	}()
+
+	return errs, stopFunc
+}
+
+// startRunnersErr runs each of the RunnerFuncErrs and returns their
+// ShutdownFuncs in the order that the runners were registered. If a runner
+// fails to start, or panics, the runners that have already started are
+// shutdown, in reverse order, before the error is returned or the panic is
+// propagated.
+func startRunnersErr(runnerFuncs []RunnerFuncErr) (shutdowns []ShutdownFunc, err error) {
+	shutdowns = make([]ShutdownFunc, 0, len(runnerFuncs))
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			shutdownInReverse(shutdowns)
+			panic(recovered)
+		}
+	}()
+
+	for idx, runner := range runnerFuncs {
+		shutdown, err := runner()
+		if err != nil {
+			shutdownInReverse(shutdowns)
+			return nil, errors.Wrapf(err, "starting runner %d", idx)
+		}
+		shutdowns = append(shutdowns, noopIfNil(shutdown))
+	}
+	return shutdowns, nil
+}
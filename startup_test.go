@@ -0,0 +1,161 @@
+package rununtil_test
+
+import (
+	"os"
+	"reflect"
+	"syscall"
+	"testing"
+
+	"github.com/mec07/rununtil"
+	"github.com/pkg/errors"
+)
+
+func helperMakeOrderedRunnerErr(idx int, order *[]int, err error) rununtil.RunnerFuncErr {
+	return rununtil.RunnerFuncErr(func() (rununtil.ShutdownFunc, error) {
+		if err != nil {
+			return nil, err
+		}
+		return rununtil.ShutdownFunc(func() {
+			*order = append(*order, idx)
+		}), nil
+	})
+}
+
+func TestRununtilAwaitKillSignalsErr(t *testing.T) {
+	var order []int
+	cancellingRunner := rununtil.RunnerFuncErr(func() (rununtil.ShutdownFunc, error) {
+		rununtil.CancelAll()
+		return rununtil.ShutdownFunc(func() {}), nil
+	})
+
+	err := rununtil.AwaitKillSignalsErr(
+		[]os.Signal{syscall.SIGINT},
+		helperMakeOrderedRunnerErr(1, &order, nil),
+		helperMakeOrderedRunnerErr(2, &order, nil),
+		cancellingRunner,
+	)
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []int{2, 1}
+	if !reflect.DeepEqual(order, expected) {
+		t.Fatalf("expected shutdown order %v, got: %v", expected, order)
+	}
+}
+
+func TestRununtilAwaitKillSignalsErr_StartupFailure(t *testing.T) {
+	var order []int
+	errStartup := errors.New("address already in use")
+	var laterRunnerStarted bool
+	laterRunner := rununtil.RunnerFuncErr(func() (rununtil.ShutdownFunc, error) {
+		laterRunnerStarted = true
+		return rununtil.ShutdownFunc(func() {}), nil
+	})
+
+	// no signal is ever sent, so this only returns because of the startup error
+	err := rununtil.AwaitKillSignalsErr(
+		[]os.Signal{syscall.SIGINT},
+		helperMakeOrderedRunnerErr(1, &order, nil),
+		helperMakeOrderedRunnerErr(2, &order, nil),
+		helperMakeOrderedRunnerErr(3, &order, errStartup),
+		laterRunner,
+	)
+
+	if errors.Cause(err) != errStartup {
+		t.Fatalf("expected the startup error to be returned, got: %v", err)
+	}
+	if laterRunnerStarted {
+		t.Fatal("did not expect the runners after the failing one to be started")
+	}
+	expected := []int{2, 1}
+	if !reflect.DeepEqual(order, expected) {
+		t.Fatalf("expected shutdown order %v, got: %v", expected, order)
+	}
+}
+
+func TestRununtilAwaitKillSignals_StartupPanic(t *testing.T) {
+	table := []struct {
+		name  string
+		await func(runnerFuncs ...rununtil.RunnerFunc)
+	}{
+		{
+			name: "AwaitKillSignals",
+			await: func(runnerFuncs ...rununtil.RunnerFunc) {
+				rununtil.AwaitKillSignals([]os.Signal{syscall.SIGINT}, runnerFuncs...)
+			},
+		},
+		{
+			name: "AwaitKillSignalsInOrder",
+			await: func(runnerFuncs ...rununtil.RunnerFunc) {
+				rununtil.AwaitKillSignalsInOrder([]os.Signal{syscall.SIGINT}, runnerFuncs...)
+			},
+		},
+		{
+			name: "AwaitKillSignalsParallel",
+			await: func(runnerFuncs ...rununtil.RunnerFunc) {
+				rununtil.AwaitKillSignalsParallel([]os.Signal{syscall.SIGINT}, runnerFuncs...)
+			},
+		},
+	}
+	for _, test := range table {
+		t.Run(test.name, func(t *testing.T) {
+			var hasBeenShutdown bool
+			panickingRunner := rununtil.RunnerFunc(func() rununtil.ShutdownFunc {
+				panic("failed to start")
+			})
+
+			defer func() {
+				if recovered := recover(); recovered != "failed to start" {
+					t.Fatalf("expected the panic to be propagated, got: %v", recovered)
+				}
+				if !hasBeenShutdown {
+					t.Fatal("expected the started runner to have been shutdown")
+				}
+			}()
+
+			test.await(helperMakeFakeRunner(&hasBeenShutdown), panickingRunner)
+		})
+	}
+}
+
+func TestRununtilStartErr(t *testing.T) {
+	var order []int
+	errs, stop := rununtil.StartErr(
+		helperMakeOrderedRunnerErr(1, &order, nil),
+		helperMakeOrderedRunnerErr(2, &order, nil),
+	)
+
+	stop()
+	stop()
+
+	if err := <-errs; err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []int{2, 1}
+	if !reflect.DeepEqual(order, expected) {
+		t.Fatalf("expected shutdown order %v, got: %v", expected, order)
+	}
+}
+
+func TestRununtilStartErr_StartupFailure(t *testing.T) {
+	var order []int
+	errStartup := errors.New("address already in use")
+
+	errs, stop := rununtil.StartErr(
+		helperMakeOrderedRunnerErr(1, &order, nil),
+		helperMakeOrderedRunnerErr(2, &order, errStartup),
+	)
+	defer stop()
+
+	if err := <-errs; errors.Cause(err) != errStartup {
+		t.Fatalf("expected the startup error to be received, got: %v", err)
+	}
+	if _, ok := <-errs; ok {
+		t.Fatal("expected the error channel to be closed after the startup error")
+	}
+	expected := []int{1}
+	if !reflect.DeepEqual(order, expected) {
+		t.Fatalf("expected shutdown order %v, got: %v", expected, order)
+	}
+}
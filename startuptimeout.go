@@ -0,0 +1,102 @@
+package rununtil
+
+import (
+	"os"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ErrStartupTimeout is the cause of the error returned by
+// AwaitKillSignalsStartupTimeout when a runner does not start in time.
+var ErrStartupTimeout = errors.New("runner did not start in time")
+
+// AwaitKillSignalsStartupTimeout runs the provided RunnerFuncs until the
+// specified signals have been recieved, at which point it executes the
+// graceful shutdown functions in the reverse order to which the runners were
+// registered, and returns nil. Each runner is given startupTimeout to return
+// its ShutdownFunc, e.g. in case it is waiting for a database connection that
+// never comes. If a runner doesn't start in time, the runners which have
+// already started are shutdown and an error, whose cause is
+// ErrStartupTimeout, is returned without waiting for a signal. Should the
+// stuck runner return later on, its ShutdownFunc is executed straight away.
+func AwaitKillSignalsStartupTimeout(signals []os.Signal, startupTimeout time.Duration, runnerFuncs ...RunnerFunc) error {
+	wait, release := defaultGroup.listenForKillSignal(signals)
+	defer release()
+
+	shutdowns := make([]ShutdownFunc, 0, len(runnerFuncs))
+	for idx, runner := range runnerFuncs {
+		shutdown, err := startWithTimeout(runner, startupTimeout)
+		if err != nil {
+			shutdownInReverse(shutdowns)
+			if recovered, ok := err.(startupPanic); ok {
+				panic(recovered.value)
+			}
+			return errors.Wrapf(err, "runner %d", idx)
+		}
+		shutdowns = append(shutdowns, shutdown)
+	}
+
+	wait()
+
+	shutdownWithinDefaultTimeout(func() { shutdownInReverse(shutdowns) })
+	return nil
+}
+
+// startupPanic carries a panic from a runner's startup go routine back to the
+// await, so that it can be propagated once the started runners are shutdown.
+type startupPanic struct {
+	value interface{}
+}
+
+func (p startupPanic) Error() string {
+	return "runner panicked while starting"
+}
+
+// startWithTimeout runs the runner in a go routine and returns its
+// ShutdownFunc, or ErrStartupTimeout if it doesn't return within timeout. In
+// that case the ShutdownFunc is executed as soon as the runner does return.
+func startWithTimeout(runner RunnerFunc, timeout time.Duration) (ShutdownFunc, error) {
+	started := make(chan ShutdownFunc, 1)
+	panicked := make(chan interface{}, 1)
+	var mux sync.Mutex
+	var abandoned bool
+	go func() {
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				panicked <- recovered
+			}
+		}()
+		shutdown := noopIfNil(runner())
+
+		mux.Lock()
+		defer mux.Unlock()
+		if abandoned {
+			shutdown()
+			return
+		}
+		started <- shutdown
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case shutdown := <-started:
+		return shutdown, nil
+	case recovered := <-panicked:
+		return nil, startupPanic{value: recovered}
+	case <-timer.C:
+	}
+
+	mux.Lock()
+	defer mux.Unlock()
+	// the runner may have returned at the same time as the timer fired
+	select {
+	case shutdown := <-started:
+		return shutdown, nil
+	default:
+	}
+	abandoned = true
+	return nil, ErrStartupTimeout
+}
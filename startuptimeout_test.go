@@ -0,0 +1,76 @@
+package rununtil_test
+
+import (
+	"os"
+	"reflect"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/mec07/rununtil"
+	"github.com/pkg/errors"
+)
+
+func TestRununtilAwaitKillSignalsStartupTimeout(t *testing.T) {
+	var order []int
+	err := rununtil.AwaitKillSignalsStartupTimeout(
+		[]os.Signal{syscall.SIGINT},
+		time.Second,
+		helperMakeOrderedRunner(1, &order),
+		helperMakeOrderedRunner(2, &order),
+		helperMakeCancellingRunner(),
+	)
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []int{2, 1}
+	if !reflect.DeepEqual(order, expected) {
+		t.Fatalf("expected shutdown order %v, got: %v", expected, order)
+	}
+}
+
+func TestRununtilAwaitKillSignalsStartupTimeout_Stuck(t *testing.T) {
+	var order []int
+	unblock := make(chan struct{})
+	stuckShutdown := make(chan struct{})
+	stuckRunner := rununtil.RunnerFunc(func() rununtil.ShutdownFunc {
+		<-unblock
+		return rununtil.ShutdownFunc(func() {
+			close(stuckShutdown)
+		})
+	})
+	var laterRunnerStarted bool
+	laterRunner := rununtil.RunnerFunc(func() rununtil.ShutdownFunc {
+		laterRunnerStarted = true
+		return nil
+	})
+
+	// no signal is ever sent, so this only returns because of the timeout
+	err := rununtil.AwaitKillSignalsStartupTimeout(
+		[]os.Signal{syscall.SIGINT},
+		10*time.Millisecond,
+		helperMakeOrderedRunner(1, &order),
+		helperMakeOrderedRunner(2, &order),
+		stuckRunner,
+		laterRunner,
+	)
+
+	if errors.Cause(err) != rununtil.ErrStartupTimeout {
+		t.Fatalf("expected a startup timeout error, got: %v", err)
+	}
+	if laterRunnerStarted {
+		t.Fatal("did not expect the runners after the stuck one to be started")
+	}
+	expected := []int{2, 1}
+	if !reflect.DeepEqual(order, expected) {
+		t.Fatalf("expected shutdown order %v, got: %v", expected, order)
+	}
+
+	close(unblock)
+	select {
+	case <-stuckShutdown:
+	case <-time.After(time.Second):
+		t.Fatal("expected the stuck runner to be shutdown once it returned")
+	}
+}
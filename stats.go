@@ -0,0 +1,61 @@
+package rununtil
+
+import (
+	"os"
+	"time"
+)
+
+// RunnerShutdownStat describes how the shutdown function of a single runner
+// behaved.
+type RunnerShutdownStat struct {
+	// Index is the index of the runner in the order that they were registered.
+	Index int
+	// Duration is how long the shutdown function took to return.
+	Duration time.Duration
+	// Panic is the value recovered from the shutdown function if it panicked,
+	// or nil otherwise.
+	Panic interface{}
+}
+
+// AwaitKillSignalsStats runs the provided RunnerFuncs until the specified
+// signals have been recieved, at which point it executes the graceful shutdown
+// functions in the reverse order to which the runners were registered. Once
+// they have all returned it returns a RunnerShutdownStat for each runner, in
+// the order that they were registered, e.g. to find the slowest subsystem:
+//
+//	stats := rununtil.AwaitKillSignalsStats(signals, NewRunner(logger))
+//	sort.Slice(stats, func(i, j int) bool { return stats[i].Duration > stats[j].Duration })
+//	log.Info().Msgf("slowest shutdown was runner %d", stats[0].Index)
+//
+// A panic in one shutdown function is recovered and recorded in its stat, so
+// it does not prevent the others from being executed.
+func AwaitKillSignalsStats(signals []os.Signal, runnerFuncs ...RunnerFunc) []RunnerShutdownStat {
+	wait, release := defaultGroup.listenForKillSignal(signals)
+	defer release()
+
+	shutdowns := startRunners(runnerFuncs)
+
+	wait()
+
+	stats := make([]RunnerShutdownStat, len(shutdowns))
+	shutdownWithinDefaultTimeout(func() {
+		for idx := len(shutdowns) - 1; idx >= 0; idx-- {
+			stats[idx] = timeShutdown(idx, shutdowns[idx])
+		}
+	})
+	return stats
+}
+
+// timeShutdown executes the shutdown function, recovering any panic, and
+// returns how it behaved.
+func timeShutdown(idx int, shutdown ShutdownFunc) (stat RunnerShutdownStat) {
+	stat.Index = idx
+	start := time.Now()
+	defer func() {
+		stat.Duration = time.Since(start)
+		stat.Panic = recover()
+	}()
+
+	shutdown()
+	return stat
+}
@@ -0,0 +1,48 @@
+package rununtil_test
+
+import (
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/mec07/rununtil"
+)
+
+func TestRununtilAwaitKillSignalsStats(t *testing.T) {
+	slowRunner := rununtil.RunnerFunc(func() rununtil.ShutdownFunc {
+		return rununtil.ShutdownFunc(func() {
+			time.Sleep(10 * time.Millisecond)
+		})
+	})
+	panickingRunner := rununtil.RunnerFunc(func() rununtil.ShutdownFunc {
+		return rununtil.ShutdownFunc(func() {
+			panic("shutdown failed")
+		})
+	})
+
+	stats := rununtil.AwaitKillSignalsStats(
+		[]os.Signal{syscall.SIGINT},
+		slowRunner,
+		panickingRunner,
+		helperMakeCancellingRunner(),
+	)
+
+	if len(stats) != 3 {
+		t.Fatalf("expected a stat for each runner, got: %d", len(stats))
+	}
+	for idx, stat := range stats {
+		if stat.Index != idx {
+			t.Fatalf("expected stat %d to have index %d, got: %d", idx, idx, stat.Index)
+		}
+	}
+	if stats[0].Duration < 10*time.Millisecond {
+		t.Fatalf("expected the slow shutdown to have taken at least 10ms, got: %v", stats[0].Duration)
+	}
+	if stats[0].Panic != nil {
+		t.Fatalf("did not expect the slow shutdown to have panicked, got: %v", stats[0].Panic)
+	}
+	if stats[1].Panic != "shutdown failed" {
+		t.Fatalf("expected the panic to have been recorded, got: %v", stats[1].Panic)
+	}
+}
@@ -0,0 +1,144 @@
+package rununtil
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// stdin is the reader watched by WithStdinEOFShutdown. It is a variable so
+// that it can be stubbed in tests.
+var stdin io.Reader = os.Stdin
+
+// WithStdinEOFShutdown also initiates the shutdown when stdin is closed, i.e.
+// when reading it hits EOF, which is how some supervisors, e.g. editors
+// running a language server or foreman-like process managers, ask a process
+// to shutdown.
+//
+// While any await which sets this is running, a single go routine reads, and
+// discards, stdin until it hits EOF. Once the last of those awaits has
+// returned, the go routine is interrupted with a read deadline and exits, so
+// that stdin can be read normally again. A read deadline can only be set if
+// stdin is a pipe or a similar pollable file: otherwise the blocking read
+// cannot be interrupted and the go routine keeps reading, and discarding,
+// stdin for the rest of the life of the process, for later awaits to share.
+func WithStdinEOFShutdown() Option {
+	return func(cfg *config) {
+		cfg.stdinEOF = true
+	}
+}
+
+// deadliner is implemented by readers, e.g. *os.File, whose blocking reads can
+// be interrupted by setting a deadline.
+type deadliner interface {
+	SetReadDeadline(t time.Time) error
+}
+
+// eofWatcher is the go routine reading a reader for WithStdinEOFShutdown.
+type eofWatcher struct {
+	// eof is closed once the reader hits EOF.
+	eof chan struct{}
+	// exited is closed once the go routine has returned.
+	exited chan struct{}
+	// awaits is the number of awaits which are watching for eof.
+	awaits int
+	// deadliner interrupts the read once awaits drops to zero, or it is nil
+	// if the read can't be interrupted.
+	deadliner deadliner
+}
+
+// eofWatchers holds the go routine reading each reader, so that only one go
+// routine reads a given reader at a time.
+var eofWatchers = struct {
+	sync.Mutex
+	watchers map[io.Reader]*eofWatcher
+}{watchers: make(map[io.Reader]*eofWatcher)}
+
+// watchStdin returns a channel which is closed once stdin hits EOF, if
+// WithStdinEOFShutdown has been set, and a function which must be called once
+// the await stops watching. Otherwise the channel is nil, so it never fires.
+func (cfg config) watchStdin() (<-chan struct{}, func()) {
+	if !cfg.stdinEOF {
+		return nil, func() {}
+	}
+	return watchForEOF(stdin)
+}
+
+// watchForEOF returns a channel which is closed once r hits EOF, starting the
+// go routine which reads r if it is not already running, and a function which
+// stops the go routine once every await watching r has called it, if its read
+// can be interrupted.
+func watchForEOF(r io.Reader) (<-chan struct{}, func()) {
+	eofWatchers.Lock()
+	defer eofWatchers.Unlock()
+
+	w, ok := eofWatchers.watchers[r]
+	if !ok {
+		w = &eofWatcher{eof: make(chan struct{}), exited: make(chan struct{})}
+		if d, ok := r.(deadliner); ok && d.SetReadDeadline(time.Time{}) == nil {
+			w.deadliner = d
+		}
+		eofWatchers.watchers[r] = w
+		go w.readUntilEOF(r)
+	}
+	w.awaits++
+
+	var once sync.Once
+	return w.eof, func() {
+		once.Do(func() { w.release(r) })
+	}
+}
+
+// release records that an await has stopped watching for EOF. Once none are
+// left, the read is interrupted and release waits for the go routine to exit,
+// so that it cannot consume anything which is read from r afterwards.
+func (w *eofWatcher) release(r io.Reader) {
+	eofWatchers.Lock()
+	defer eofWatchers.Unlock()
+
+	w.awaits--
+	if w.awaits > 0 || w.deadliner == nil {
+		return
+	}
+	delete(eofWatchers.watchers, r)
+	select {
+	case <-w.eof:
+		// the go routine has already returned
+		return
+	default:
+	}
+	if err := w.deadliner.SetReadDeadline(time.Now()); err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %+v\n", errors.Wrap(err, "interrupting the read of stdin"))
+		return
+	}
+	<-w.exited
+	_ = w.deadliner.SetReadDeadline(time.Time{})
+}
+
+// readUntilEOF reads, and discards, everything from r and closes eof once it
+// hits EOF. It returns quietly if the read is interrupted by release. Any
+// other error is reported on stderr, and then eof is never closed.
+func (w *eofWatcher) readUntilEOF(r io.Reader) {
+	defer close(w.exited)
+
+	buf := make([]byte, 512)
+	for {
+		_, err := r.Read(buf)
+		if err == nil {
+			continue
+		}
+		if err == io.EOF {
+			close(w.eof)
+			return
+		}
+		if w.deadliner != nil && os.IsTimeout(err) {
+			return
+		}
+		fmt.Fprintf(os.Stderr, "ERROR: %+v\n", errors.Wrap(err, "reading stdin, no longer watching for EOF"))
+		return
+	}
+}
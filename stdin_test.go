@@ -0,0 +1,99 @@
+package rununtil_test
+
+import (
+	"io"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/mec07/rununtil"
+)
+
+func TestRununtilAwait_StdinEOFShutdown(t *testing.T) {
+	pr, pw := io.Pipe()
+	restore := rununtil.SetStdin(pr)
+	defer restore()
+
+	var hasBeenShutdown bool
+	closingRunner := rununtil.RunnerFunc(func() rununtil.ShutdownFunc {
+		go func() {
+			_, _ = pw.Write([]byte("ignored"))
+			_ = pw.Close()
+		}()
+		return nil
+	})
+	done := make(chan rununtil.ShutdownReason)
+	go func() {
+		done <- rununtil.AwaitReason(
+			[]rununtil.RunnerFunc{helperMakeFakeRunner(&hasBeenShutdown), closingRunner},
+			rununtil.WithStdinEOFShutdown(),
+		)
+	}()
+
+	select {
+	case reason := <-done:
+		if reason != rununtil.ReasonStdinEOF {
+			t.Fatalf("expected the shutdown to have been triggered by stdin closing, got: %v", reason)
+		}
+	case <-time.After(time.Second):
+		rununtil.CancelAll()
+		t.Fatal("expected closing stdin to have stopped the await")
+	}
+	if !hasBeenShutdown {
+		t.Fatal("expected the shutdown function to have been called")
+	}
+}
+
+func TestRununtilAwait_StdinEOFShutdownFile(t *testing.T) {
+	pr, pw, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer pr.Close()
+	restore := rununtil.SetStdin(pr)
+	defer restore()
+
+	// the first await stops before stdin is closed, which stops the go
+	// routine reading stdin
+	reason := rununtil.AwaitReason(
+		[]rununtil.RunnerFunc{helperMakeCancellingRunner()},
+		rununtil.WithStdinEOFShutdown(),
+	)
+	if reason != rununtil.ReasonSimulated {
+		t.Fatalf("expected the shutdown to have been simulated, got: %v", reason)
+	}
+	written := "read after the await returned"
+	if _, err := pw.Write([]byte(written)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	buf := make([]byte, len(written))
+	_ = pr.SetReadDeadline(time.Now().Add(time.Second))
+	_, err = io.ReadFull(pr, buf)
+	_ = pr.SetReadDeadline(time.Time{})
+	if err != nil || string(buf) != written {
+		t.Fatalf("expected stdin to no longer be consumed once the await had returned, got %q: %v", buf, err)
+	}
+
+	// the second await starts a new go routine, which sees the EOF
+	closingRunner := rununtil.RunnerFunc(func() rununtil.ShutdownFunc {
+		go pw.Close()
+		return nil
+	})
+	done := make(chan rununtil.ShutdownReason)
+	go func() {
+		done <- rununtil.AwaitReason(
+			[]rununtil.RunnerFunc{closingRunner},
+			rununtil.WithStdinEOFShutdown(),
+		)
+	}()
+
+	select {
+	case reason := <-done:
+		if reason != rununtil.ReasonStdinEOF {
+			t.Fatalf("expected the shutdown to have been triggered by stdin closing, got: %v", reason)
+		}
+	case <-time.After(time.Second):
+		rununtil.CancelAll()
+		t.Fatal("expected closing stdin to have stopped the await")
+	}
+}
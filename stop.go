@@ -0,0 +1,40 @@
+package rununtil
+
+import (
+	"context"
+	"os"
+)
+
+// RunnerFuncStop is a nonblocking function that sets off the worker go
+// routines and returns a function which can shutdown those worker go routines.
+// The stop channel it is given is closed as soon as a shutdown has been
+// initiated, before any of the shutdown functions are called, so the worker go
+// routines can select on it to start winding down while the ShutdownFunc does
+// the final clean up, e.g. closing a connection.
+type RunnerFuncStop func(stop <-chan struct{}) ShutdownFunc
+
+// AwaitKillSignalStop runs the provided RunnerFuncStops until it receives a
+// kill signal, SIGINT or SIGTERM, at which point it closes the stop channel
+// passed to the runners and then executes the graceful shutdown functions.
+func AwaitKillSignalStop(runnerFuncs ...RunnerFuncStop) {
+	AwaitKillSignalsStop(defaultSignals(), runnerFuncs...)
+}
+
+// AwaitKillSignalsStop runs the provided RunnerFuncStops until the specified
+// signals have been recieved, at which point it closes the stop channel passed
+// to the runners and then executes the graceful shutdown functions in the
+// reverse order to which the runners were registered.
+func AwaitKillSignalsStop(signals []os.Signal, runnerFuncs ...RunnerFuncStop) {
+	stop := make(chan struct{})
+	wrapped := make([]RunnerFunc, len(runnerFuncs))
+	for idx, runner := range runnerFuncs {
+		runner := runner
+		wrapped[idx] = func() ShutdownFunc {
+			return runner(stop)
+		}
+	}
+
+	Await(wrapped, WithSignals(signals...), WithPreShutdown(func(ctx context.Context) {
+		close(stop)
+	}))
+}
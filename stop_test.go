@@ -0,0 +1,76 @@
+package rununtil_test
+
+import (
+	"context"
+	"os"
+	"syscall"
+	"testing"
+
+	"github.com/mec07/rununtil"
+)
+
+func TestRununtilAwaitKillSignalsStop(t *testing.T) {
+	var stoppedBeforeShutdown bool
+	workerStopped := make(chan struct{})
+	worker := rununtil.RunnerFuncStop(func(stop <-chan struct{}) rununtil.ShutdownFunc {
+		go func() {
+			<-stop
+			close(workerStopped)
+		}()
+		return rununtil.ShutdownFunc(func() {
+			<-workerStopped
+			stoppedBeforeShutdown = true
+		})
+	})
+	cancelling := rununtil.RunnerFuncStop(func(stop <-chan struct{}) rununtil.ShutdownFunc {
+		select {
+		case <-stop:
+			t.Error("did not expect the stop channel to be closed before the shutdown was initiated")
+		default:
+		}
+		rununtil.CancelAll()
+		return nil
+	})
+
+	rununtil.AwaitKillSignalsStop([]os.Signal{syscall.SIGINT}, worker, cancelling)
+
+	if !stoppedBeforeShutdown {
+		t.Fatal("expected the stop channel to have been closed before the shutdown function was called")
+	}
+}
+
+func TestRununtilAwaitKillSignalsStop_GroupOptions(t *testing.T) {
+	var groupPreShutdown, groupHook bool
+	original := rununtil.DefaultGroup()
+	defer rununtil.SetDefaultGroup(original)
+	rununtil.SetDefaultGroup(rununtil.NewGroup(rununtil.WithOptions(
+		rununtil.WithPreShutdown(func(ctx context.Context) { groupPreShutdown = true }),
+		rununtil.WithHooks(rununtil.Hooks{
+			OnShutdownStart: func(ctx context.Context) { groupHook = true },
+		}),
+	)))
+
+	var stopped bool
+	worker := rununtil.RunnerFuncStop(func(stop <-chan struct{}) rununtil.ShutdownFunc {
+		return rununtil.ShutdownFunc(func() {
+			select {
+			case <-stop:
+				stopped = true
+			default:
+			}
+		})
+	})
+	cancelling := rununtil.RunnerFuncStop(func(stop <-chan struct{}) rununtil.ShutdownFunc {
+		rununtil.CancelAll()
+		return nil
+	})
+
+	rununtil.AwaitKillSignalsStop([]os.Signal{syscall.SIGINT}, worker, cancelling)
+
+	if !stopped {
+		t.Fatal("expected the stop channel to have been closed before the shutdown function was called")
+	}
+	if !groupPreShutdown || !groupHook {
+		t.Fatalf("expected the pre-shutdown function and hooks of the group to still be called, got pre-shutdown %v and hook %v", groupPreShutdown, groupHook)
+	}
+}
@@ -0,0 +1,165 @@
+package rununtil
+
+import (
+	"math"
+	"math/rand"
+	"os/signal"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// SupervisedRunnerFunc is a nonblocking function that sets off the worker go
+// routines and returns a function which can shutdown those worker go
+// routines, along with a channel on which the worker go routines report that
+// they have died. Closing the channel without sending anything on it means
+// that the worker go routines finished cleanly and do not need restarting.
+type SupervisedRunnerFunc func() (ShutdownFunc, <-chan error)
+
+// RestartPolicy determines how Supervise restarts runners which have died.
+type RestartPolicy struct {
+	// MaxAttempts is the number of consecutive times a runner is restarted
+	// before Supervise gives up on it.
+	MaxAttempts int
+	// Backoff is how long to wait before restarting a runner the first time.
+	// It doubles with every consecutive restart.
+	Backoff time.Duration
+	// Jitter randomises each backoff by up to this fraction of it in either
+	// direction, e.g. 0.2 means +/-20%, so that runners which died at the same
+	// time are not all restarted at the same time.
+	Jitter float64
+	// MaxBackoff caps the backoff. If it is zero the backoff is not capped.
+	MaxBackoff time.Duration
+	// HealthyThreshold is how long a runner has to stay running for before its
+	// restarts are no longer considered consecutive, i.e. the backoff and the
+	// attempts are reset. If it is zero they are never reset.
+	HealthyThreshold time.Duration
+}
+
+// randFloat64 returns a random number in [0.0, 1.0). It is a variable so that
+// it can be stubbed in tests.
+var randFloat64 = rand.Float64
+
+// backoff returns how long to wait before restarting a runner which has
+// already been restarted the given number of consecutive times.
+func (p RestartPolicy) backoff(restarts int) time.Duration {
+	backoff := float64(p.Backoff) * math.Pow(2, float64(restarts))
+	if p.Jitter > 0 {
+		backoff *= 1 + p.Jitter*(2*randFloat64()-1)
+	}
+	if p.MaxBackoff > 0 && backoff > float64(p.MaxBackoff) {
+		return p.MaxBackoff
+	}
+	return time.Duration(backoff)
+}
+
+// Supervise runs the provided SupervisedRunnerFuncs until it receives a kill
+// signal, SIGINT or SIGTERM, at which point it executes the graceful shutdown
+// functions in the reverse order to which the runners were registered and
+// returns nil.
+// Whenever a runner reports that it has died, its shutdown function is called
+// to clean up whatever is left of it and, after waiting for the backoff, it is
+// started again. Once a runner has died more than policy.MaxAttempts times,
+// Supervise gives up: all of the other runners are shutdown and the error
+// reported by the runner is returned.
+func Supervise(policy RestartPolicy, runnerFuncs ...SupervisedRunnerFunc) error {
+	c, finish, release := defaultGroup.killSignalChannels(signal.Notify, defaultSignals())
+	defer release()
+
+	stop := make(chan struct{})
+	failed := make(chan error, len(runnerFuncs))
+	supervisors := make([]*supervisor, 0, len(runnerFuncs))
+	var wg sync.WaitGroup
+	for idx, runner := range runnerFuncs {
+		s := &supervisor{index: idx, runner: runner, policy: policy}
+		s.start()
+		supervisors = append(supervisors, s)
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.supervise(stop, failed)
+		}()
+	}
+
+	var err error
+	select {
+	case <-c:
+	case <-finish:
+	case err = <-failed:
+	}
+	defaultGroup.initiateShutdown()
+
+	close(stop)
+	wg.Wait()
+	shutdowns := make([]ShutdownFunc, 0, len(supervisors))
+	for _, s := range supervisors {
+		shutdowns = append(shutdowns, s.shutdownIfRunning)
+	}
+	shutdownWithinDefaultTimeout(func() { shutdownInReverse(shutdowns) })
+
+	return err
+}
+
+// supervisor keeps a single runner running according to the restart policy.
+type supervisor struct {
+	index    int
+	runner   SupervisedRunnerFunc
+	policy   RestartPolicy
+	shutdown ShutdownFunc
+	died     <-chan error
+	started  time.Time
+}
+
+func (s *supervisor) start() {
+	s.started = time.Now()
+	s.shutdown, s.died = s.runner()
+}
+
+func (s *supervisor) shutdownIfRunning() {
+	if s.shutdown != nil {
+		s.shutdown()
+		s.shutdown = nil
+	}
+}
+
+// supervise restarts the runner every time it dies, until either stop is
+// closed or the runner has used up its restart attempts, in which case the
+// error is sent on failed.
+func (s *supervisor) supervise(stop <-chan struct{}, failed chan<- error) {
+	for attempts := 0; ; attempts++ {
+		var err error
+		select {
+		case <-stop:
+			return
+		case diedErr, ok := <-s.died:
+			if !ok {
+				// finished cleanly, so there is nothing left to supervise
+				s.died = nil
+				attempts--
+				continue
+			}
+			err = diedErr
+		}
+		if err == nil {
+			err = errors.New("runner died")
+		}
+		if s.policy.HealthyThreshold > 0 && time.Since(s.started) >= s.policy.HealthyThreshold {
+			attempts = 0
+		}
+
+		s.shutdownIfRunning()
+		if attempts >= s.policy.MaxAttempts {
+			failed <- errors.Wrapf(err, "runner %d died after %d restarts", s.index, attempts)
+			return
+		}
+
+		select {
+		case <-stop:
+			return
+		case <-time.After(s.policy.backoff(attempts)):
+		}
+		s.start()
+	}
+}
@@ -0,0 +1,163 @@
+package rununtil_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mec07/rununtil"
+	"github.com/pkg/errors"
+)
+
+// helperMakeCrashingRunner returns a runner which dies straight away the first
+// crashes times it is started, and then keeps running until it is shutdown.
+func helperMakeCrashingRunner(crashes int, errCrash error, starts, shutdowns *int) rununtil.SupervisedRunnerFunc {
+	return rununtil.SupervisedRunnerFunc(func() (rununtil.ShutdownFunc, <-chan error) {
+		*starts++
+		died := make(chan error, 1)
+		if *starts <= crashes {
+			died <- errCrash
+		}
+		return rununtil.ShutdownFunc(func() {
+			*shutdowns++
+		}), died
+	})
+}
+
+func TestSupervise_Restarts(t *testing.T) {
+	var starts, shutdowns int
+	// the crashing runner is only restarted once the await is listening, so
+	// waiting for the restart before cancelling is deterministic
+	restarted := make(chan struct{})
+	crashing := helperMakeCrashingRunner(1, errors.New("crashed"), &starts, &shutdowns)
+	runner := rununtil.SupervisedRunnerFunc(func() (rununtil.ShutdownFunc, <-chan error) {
+		shutdown, died := crashing()
+		if starts == 2 {
+			close(restarted)
+		}
+		return shutdown, died
+	})
+	go func() {
+		<-restarted
+		rununtil.CancelAll()
+	}()
+
+	err := rununtil.Supervise(rununtil.RestartPolicy{MaxAttempts: 3, Backoff: time.Millisecond}, runner)
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if starts != 2 {
+		t.Fatalf("expected the runner to have been started twice, got: %d", starts)
+	}
+	if shutdowns != 2 {
+		t.Fatalf("expected the runner to have been shutdown twice, got: %d", shutdowns)
+	}
+}
+
+func TestSupervise_GivesUp(t *testing.T) {
+	errCrash := errors.New("crashed")
+	var starts, shutdowns int
+	var healthyStarts, healthyShutdowns int
+
+	err := rununtil.Supervise(
+		rununtil.RestartPolicy{MaxAttempts: 2, Backoff: time.Millisecond},
+		helperMakeCrashingRunner(0, nil, &healthyStarts, &healthyShutdowns),
+		helperMakeCrashingRunner(100, errCrash, &starts, &shutdowns),
+	)
+
+	if errors.Cause(err) != errCrash {
+		t.Fatalf("expected the crash error to be returned, got: %v", err)
+	}
+	if starts != 3 {
+		t.Fatalf("expected the crashing runner to have been started 3 times, got: %d", starts)
+	}
+	if shutdowns != 3 {
+		t.Fatalf("expected the crashing runner to have been shutdown 3 times, got: %d", shutdowns)
+	}
+	if healthyStarts != 1 || healthyShutdowns != 1 {
+		t.Fatal("expected the healthy runner to have been started and shutdown once")
+	}
+}
+
+func TestRestartPolicyBackoff(t *testing.T) {
+	table := []struct {
+		name     string
+		policy   rununtil.RestartPolicy
+		random   float64
+		restarts int
+		expected time.Duration
+	}{
+		{
+			name:     "First restart",
+			policy:   rununtil.RestartPolicy{Backoff: 100 * time.Millisecond},
+			restarts: 0,
+			expected: 100 * time.Millisecond,
+		},
+		{
+			name:     "Exponential",
+			policy:   rununtil.RestartPolicy{Backoff: 100 * time.Millisecond},
+			restarts: 3,
+			expected: 800 * time.Millisecond,
+		},
+		{
+			name:     "Capped",
+			policy:   rununtil.RestartPolicy{Backoff: 100 * time.Millisecond, MaxBackoff: 500 * time.Millisecond},
+			restarts: 3,
+			expected: 500 * time.Millisecond,
+		},
+		{
+			name:     "Jitter down",
+			policy:   rununtil.RestartPolicy{Backoff: 100 * time.Millisecond, Jitter: 0.5},
+			random:   0,
+			restarts: 0,
+			expected: 50 * time.Millisecond,
+		},
+		{
+			name:     "Jitter up",
+			policy:   rununtil.RestartPolicy{Backoff: 100 * time.Millisecond, Jitter: 0.5},
+			random:   0.75,
+			restarts: 1,
+			expected: 250 * time.Millisecond,
+		},
+	}
+	for _, test := range table {
+		t.Run(test.name, func(t *testing.T) {
+			restore := rununtil.SetRandFloat64(func() float64 { return test.random })
+			defer restore()
+
+			if backoff := rununtil.Backoff(test.policy, test.restarts); backoff != test.expected {
+				t.Fatalf("expected a backoff of %v, got: %v", test.expected, backoff)
+			}
+		})
+	}
+}
+
+func TestSupervise_HealthyThresholdResetsAttempts(t *testing.T) {
+	var starts int
+	runner := rununtil.SupervisedRunnerFunc(func() (rununtil.ShutdownFunc, <-chan error) {
+		starts++
+		died := make(chan error, 1)
+		if starts <= 3 {
+			// stay healthy for longer than the threshold before dying
+			go func() {
+				time.Sleep(10 * time.Millisecond)
+				died <- errors.New("crashed")
+			}()
+		} else {
+			rununtil.CancelAll()
+		}
+		return rununtil.ShutdownFunc(func() {}), died
+	})
+
+	err := rununtil.Supervise(
+		rununtil.RestartPolicy{MaxAttempts: 1, Backoff: time.Millisecond, HealthyThreshold: 5 * time.Millisecond},
+		runner,
+	)
+
+	if err != nil {
+		t.Fatalf("expected the attempts to be reset by the healthy runs, got: %v", err)
+	}
+	if starts != 4 {
+		t.Fatalf("expected the runner to have been started 4 times, got: %d", starts)
+	}
+}
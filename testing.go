@@ -0,0 +1,108 @@
+package rununtil
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// readyTimeout is how long RunUntilReady waits for main to become ready, and
+// for main to return once it has been cancelled, before failing the test.
+const readyTimeout = 5 * time.Second
+
+// RunUntilReady is used for testing a function that is using one of the
+// awaits. It runs main in a go routine and polls ready, with a short backoff,
+// until it returns true, failing the test if main is not ready in time. The
+// returned cancel function simulates a kill signal and waits for main to
+// return, so that the test can safely inspect the results of the shutdown. A
+// sample usage of this could be:
+//
+//	cancel := rununtil.RunUntilReady(t, main, func() bool {
+//		resp, err := http.Get("http://localhost:8080/healthz")
+//		return err == nil && resp.StatusCode == http.StatusOK
+//	})
+//	... do some stuff, e.g. send some requests to the webserver ...
+//	cancel()
+func RunUntilReady(t testing.TB, main func(), ready func() bool) (cancel func()) {
+	t.Helper()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		main()
+	}()
+
+	deadline := time.Now().Add(readyTimeout)
+	backoff := time.Millisecond
+	for !ready() {
+		select {
+		case <-done:
+			t.Fatal("main returned before it was ready")
+		default:
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("main was not ready within %v", readyTimeout)
+		}
+		time.Sleep(backoff)
+		if backoff < 100*time.Millisecond {
+			backoff *= 2
+		}
+	}
+
+	return func() {
+		t.Helper()
+
+		// only the default Group, so that awaits on other Groups, e.g. in
+		// parallel tests, are not affected
+		defaultGroup.SimulateKillSignal()
+		select {
+		case <-done:
+		case <-time.After(readyTimeout):
+			t.Fatalf("main did not return within %v of being cancelled", readyTimeout)
+		}
+	}
+}
+
+// Reset stops any awaits which are still running on the default Group, e.g.
+// ones leaked by a previous test, by closing their outstanding channels, and
+// clears the default Group's state so that they cannot interfere with later
+// tests. It is intended for use in TestMain or test cleanup:
+//
+//	t.Cleanup(rununtil.Reset)
+//
+// It is only meant for tests: it is not safe to call in production while
+// awaits are expected to keep running. Note that the channel returned by
+// ShutdownInitiated stays closed once a shutdown has been initiated.
+func Reset() {
+	defaultGroup.canceller.reset()
+	defaultGroup.takeAdded()
+	defaultGroup.takeCrash()
+	defaultGroup.takeJobErr()
+}
+
+// RecordShutdownOrder returns a factory for runners whose ShutdownFuncs append
+// their index to the returned slice, so that a test can assert the order in
+// which the shutdown functions were executed:
+//
+//	runner, order := rununtil.RecordShutdownOrder()
+//	rununtil.AwaitKillSignalsInOrder(signals, runner(1), runner(2), cancelling)
+//	if !reflect.DeepEqual(*order, []int{1, 2}) {
+//		t.Fatalf("unexpected shutdown order: %v", *order)
+//	}
+//
+// The appends are safe for shutdown functions which run concurrently, but the
+// slice must only be read once the await has returned.
+func RecordShutdownOrder() (runner func(index int) RunnerFunc, order *[]int) {
+	var mux sync.Mutex
+	order = &[]int{}
+	runner = func(index int) RunnerFunc {
+		return func() ShutdownFunc {
+			return func() {
+				mux.Lock()
+				defer mux.Unlock()
+				*order = append(*order, index)
+			}
+		}
+	}
+	return runner, order
+}
@@ -0,0 +1,98 @@
+package rununtil_test
+
+import (
+	"os"
+	"reflect"
+	"sort"
+	"sync/atomic"
+	"syscall"
+	"testing"
+
+	"github.com/mec07/rununtil"
+)
+
+func TestRunUntilReady(t *testing.T) {
+	var hasBeenKilled bool
+	var polls int32
+	main, ready := helperMakeReadyMain(&hasBeenKilled)
+	slowReady := func() bool {
+		// only report ready after a few polls, to exercise the backoff
+		return atomic.AddInt32(&polls, 1) > 3 && ready()
+	}
+
+	cancel := rununtil.RunUntilReady(t, main, slowReady)
+	cancel()
+
+	if !hasBeenKilled {
+		t.Fatal("expected main to have been killed")
+	}
+}
+
+func TestReset(t *testing.T) {
+	started := make(chan struct{})
+	done := make(chan struct{})
+	var hasBeenShutdown bool
+	go func() {
+		defer close(done)
+		rununtil.AwaitKillSignal(helperMakeStartedRunner(started, &hasBeenShutdown))
+	}()
+	<-started
+
+	rununtil.Reset()
+	<-done
+
+	if !hasBeenShutdown {
+		t.Fatal("expected the leaked await to have been shutdown")
+	}
+	if count := rununtil.ActiveCount(); count != 0 {
+		t.Fatalf("expected no active awaits after a reset, got: %d", count)
+	}
+}
+
+func TestRecordShutdownOrder(t *testing.T) {
+	table := []struct {
+		name     string
+		await    func(runnerFuncs ...rununtil.RunnerFunc)
+		expected []int
+		// concurrent shutdowns can be recorded in any order
+		concurrent bool
+	}{
+		{
+			name: "AwaitKillSignals",
+			await: func(runnerFuncs ...rununtil.RunnerFunc) {
+				rununtil.AwaitKillSignals([]os.Signal{syscall.SIGINT}, runnerFuncs...)
+			},
+			expected: []int{2, 1, 0},
+		},
+		{
+			name: "AwaitKillSignalsInOrder",
+			await: func(runnerFuncs ...rununtil.RunnerFunc) {
+				rununtil.AwaitKillSignalsInOrder([]os.Signal{syscall.SIGINT}, runnerFuncs...)
+			},
+			expected: []int{0, 1, 2},
+		},
+		{
+			name: "AwaitKillSignalsParallel",
+			await: func(runnerFuncs ...rununtil.RunnerFunc) {
+				rununtil.AwaitKillSignalsParallel([]os.Signal{syscall.SIGINT}, runnerFuncs...)
+			},
+			expected:   []int{0, 1, 2},
+			concurrent: true,
+		},
+	}
+
+	for _, test := range table {
+		t.Run(test.name, func(t *testing.T) {
+			runner, order := rununtil.RecordShutdownOrder()
+			test.await(runner(0), runner(1), helperMakeCancellingRunner(), runner(2))
+
+			got := *order
+			if test.concurrent {
+				sort.Ints(got)
+			}
+			if !reflect.DeepEqual(got, test.expected) {
+				t.Fatalf("expected shutdown order %v, got: %v", test.expected, got)
+			}
+		})
+	}
+}
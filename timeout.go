@@ -0,0 +1,85 @@
+package rununtil
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+// AwaitKillSignalsWithTimeout runs the provided RunnerFuncs until the specified
+// signals have been recieved, at which point it executes all of the graceful
+// shutdown functions concurrently. The timeout applies to all of the shutdown
+// functions collectively: if they have not all returned once it has elapsed,
+// the process is forced to exit with status code 1. Any shutdown functions
+// that are still running at that point are abandoned, i.e. they do not get a
+// chance to finish.
+func AwaitKillSignalsWithTimeout(signals []os.Signal, timeout time.Duration, runnerFuncs ...RunnerFunc) {
+	Await(runnerFuncs, WithSignals(signals...), WithTimeout(timeout), WithParallelShutdown())
+}
+
+// TimeoutFromEnv returns the duration in the environment variable key, e.g.
+// SHUTDOWN_TIMEOUT=45s, so that the shutdown timeout can be configured without
+// recompiling. If the variable is unset or empty fallback is returned, and if
+// it is not a valid duration, as parsed by time.ParseDuration, the problem is
+// reported on stderr and fallback is returned.
+func TimeoutFromEnv(key string, fallback time.Duration) time.Duration {
+	return timeoutFromEnv(key, fallback, stderrLogger{})
+}
+
+// timeoutFromEnv is the same as TimeoutFromEnv except that a value which is
+// not a valid duration is reported to the provided Logger.
+func timeoutFromEnv(key string, fallback time.Duration, logger Logger) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+	timeout, err := time.ParseDuration(value)
+	if err != nil {
+		logger.Infof("WARNING: invalid %s %q, using %v instead: %v", key, value, fallback, err)
+		return fallback
+	}
+	return timeout
+}
+
+// AwaitKillSignalsWithTimeoutFromEnv is the same as AwaitKillSignalsWithTimeout
+// except that the timeout is read from the environment variable key, falling
+// back to fallback, see TimeoutFromEnv.
+func AwaitKillSignalsWithTimeoutFromEnv(signals []os.Signal, key string, fallback time.Duration, runnerFuncs ...RunnerFunc) {
+	Await(runnerFuncs, WithSignals(signals...), WithTimeoutFromEnv(key, fallback), WithParallelShutdown())
+}
+
+// shutdownWithinDefaultTimeout executes shutdown and, if DefaultShutdownTimeout
+// is set, forces the process to exit with status code 1 if it has not returned
+// once the timeout has elapsed, in the same way as WithTimeout. It is used by
+// the awaits which are not built on Await, so that they honour the default
+// too. A panic in shutdown is propagated once it has returned.
+func shutdownWithinDefaultTimeout(shutdown func()) {
+	timeout := DefaultShutdownTimeout
+	if timeout <= 0 {
+		shutdown()
+		return
+	}
+
+	done := make(chan interface{}, 1)
+	go func() {
+		defer close(done)
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				done <- recovered
+			}
+		}()
+		shutdown()
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case recovered := <-done:
+		if recovered != nil {
+			panic(recovered)
+		}
+	case <-timer.C:
+		fmt.Fprintf(os.Stderr, "ERROR: shutdown functions still running after DefaultShutdownTimeout of %v, forcing exit\n", timeout)
+		osExit(1)
+	}
+}
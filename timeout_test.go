@@ -0,0 +1,216 @@
+package rununtil_test
+
+import (
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/mec07/rununtil"
+)
+
+func TestRununtilAwaitKillSignalsWithTimeout(t *testing.T) {
+	var exitCalled bool
+	restore := rununtil.SetOsExit(func(code int) { exitCalled = true })
+	defer restore()
+
+	var hasBeenShutdown1, hasBeenShutdown2 bool
+	rununtil.AwaitKillSignalsWithTimeout(
+		[]os.Signal{syscall.SIGINT},
+		time.Second,
+		helperMakeFakeRunner(&hasBeenShutdown1),
+		helperMakeFakeRunner(&hasBeenShutdown2),
+		helperMakeCancellingRunner(),
+	)
+
+	if !hasBeenShutdown1 || !hasBeenShutdown2 {
+		t.Fatal("expected all the shutdown functions to have been called")
+	}
+	if exitCalled {
+		t.Fatal("did not expect the process to be forced to exit")
+	}
+}
+
+func TestRununtilAwaitKillSignalsWithTimeout_Concurrent(t *testing.T) {
+	var exitCalled bool
+	restore := rununtil.SetOsExit(func(code int) { exitCalled = true })
+	defer restore()
+
+	// each shutdown function blocks until the other one has started, so they
+	// can only complete if they are run concurrently
+	started1 := make(chan struct{})
+	started2 := make(chan struct{})
+	runner := func(mine, other chan struct{}) rununtil.RunnerFunc {
+		return rununtil.RunnerFunc(func() rununtil.ShutdownFunc {
+			return rununtil.ShutdownFunc(func() {
+				close(mine)
+				<-other
+			})
+		})
+	}
+
+	rununtil.AwaitKillSignalsWithTimeout(
+		[]os.Signal{syscall.SIGINT},
+		time.Second,
+		runner(started1, started2),
+		runner(started2, started1),
+		helperMakeCancellingRunner(),
+	)
+
+	if exitCalled {
+		t.Fatal("did not expect the process to be forced to exit")
+	}
+}
+
+func TestRununtilAwaitKillSignalsWithTimeout_Exceeded(t *testing.T) {
+	exitCode := -1
+	restore := rununtil.SetOsExit(func(code int) { exitCode = code })
+	defer restore()
+
+	blocked := make(chan struct{})
+	defer close(blocked)
+	stuckRunner := rununtil.RunnerFunc(func() rununtil.ShutdownFunc {
+		return rununtil.ShutdownFunc(func() {
+			<-blocked
+		})
+	})
+
+	rununtil.AwaitKillSignalsWithTimeout(
+		[]os.Signal{syscall.SIGINT},
+		10*time.Millisecond,
+		stuckRunner,
+		helperMakeCancellingRunner(),
+	)
+
+	if exitCode != 1 {
+		t.Fatalf("expected the process to be forced to exit with code 1, got: %d", exitCode)
+	}
+}
+
+func TestTimeoutFromEnv(t *testing.T) {
+	const key = "RUNUNTIL_TEST_SHUTDOWN_TIMEOUT"
+	table := []struct {
+		name     string
+		value    string
+		expected time.Duration
+	}{
+		{name: "Unset", value: "", expected: time.Minute},
+		{name: "Valid", value: "45s", expected: 45 * time.Second},
+		{name: "Invalid", value: "forty five seconds", expected: time.Minute},
+	}
+
+	for _, test := range table {
+		t.Run(test.name, func(t *testing.T) {
+			os.Setenv(key, test.value)
+			defer os.Unsetenv(key)
+
+			if timeout := rununtil.TimeoutFromEnv(key, time.Minute); timeout != test.expected {
+				t.Fatalf("expected %v, got: %v", test.expected, timeout)
+			}
+		})
+	}
+}
+
+func TestRununtilAwait_TimeoutFromEnvInvalid(t *testing.T) {
+	const key = "RUNUNTIL_TEST_SHUTDOWN_TIMEOUT"
+	os.Setenv(key, "soon")
+	defer os.Unsetenv(key)
+	logger := &recordingLogger{}
+
+	rununtil.Await(
+		[]rununtil.RunnerFunc{helperMakeCancellingRunner()},
+		rununtil.WithTimeoutFromEnv(key, time.Second),
+		rununtil.WithLogger(logger),
+	)
+
+	expected := `WARNING: invalid RUNUNTIL_TEST_SHUTDOWN_TIMEOUT "soon", using 1s instead: time: invalid duration "soon"`
+	if len(logger.messages) == 0 || logger.messages[0] != expected {
+		t.Fatalf("expected the invalid timeout to have been logged first, got: %q", logger.messages)
+	}
+}
+
+func TestRununtilAwaitKillSignalsWithTimeoutFromEnv(t *testing.T) {
+	const key = "RUNUNTIL_TEST_SHUTDOWN_TIMEOUT"
+	os.Setenv(key, "10ms")
+	defer os.Unsetenv(key)
+	exitCode := -1
+	restore := rununtil.SetOsExit(func(code int) { exitCode = code })
+	defer restore()
+
+	blocked := make(chan struct{})
+	defer close(blocked)
+	stuckRunner := rununtil.RunnerFunc(func() rununtil.ShutdownFunc {
+		return rununtil.ShutdownFunc(func() {
+			<-blocked
+		})
+	})
+
+	rununtil.AwaitKillSignalsWithTimeoutFromEnv(
+		[]os.Signal{syscall.SIGINT},
+		key,
+		time.Minute,
+		stuckRunner,
+		helperMakeCancellingRunner(),
+	)
+
+	if exitCode != 1 {
+		t.Fatalf("expected the timeout from the environment to force an exit with code 1, got: %d", exitCode)
+	}
+}
+
+func TestDefaultShutdownTimeout_AwaitsNotBuiltOnAwait(t *testing.T) {
+	defer func(timeout time.Duration) { rununtil.DefaultShutdownTimeout = timeout }(rununtil.DefaultShutdownTimeout)
+	rununtil.DefaultShutdownTimeout = 10 * time.Millisecond
+
+	table := []struct {
+		name  string
+		await func(runnerFuncs ...rununtil.RunnerFunc)
+	}{
+		{
+			name: "AwaitKillSignalsInOrder",
+			await: func(runnerFuncs ...rununtil.RunnerFunc) {
+				rununtil.AwaitKillSignalsInOrder([]os.Signal{syscall.SIGINT}, runnerFuncs...)
+			},
+		},
+		{
+			name: "AwaitKillSignalsStats",
+			await: func(runnerFuncs ...rununtil.RunnerFunc) {
+				rununtil.AwaitKillSignalsStats([]os.Signal{syscall.SIGINT}, runnerFuncs...)
+			},
+		},
+		{
+			name: "Start",
+			await: func(runnerFuncs ...rununtil.RunnerFunc) {
+				rununtil.Start(runnerFuncs...).Wait()
+			},
+		},
+	}
+	for _, test := range table {
+		t.Run(test.name, func(t *testing.T) {
+			exitCode := -1
+			restore := rununtil.SetOsExit(func(code int) { exitCode = code })
+			defer restore()
+
+			release := make(chan struct{})
+			defer close(release)
+			stuckRunner := rununtil.RunnerFunc(func() rununtil.ShutdownFunc {
+				return func() { <-release }
+			})
+
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				test.await(stuckRunner, helperMakeCancellingRunner())
+			}()
+
+			select {
+			case <-done:
+			case <-time.After(time.Second):
+				t.Fatal("expected the stuck shutdown to be abandoned once DefaultShutdownTimeout elapsed")
+			}
+			if exitCode != 1 {
+				t.Fatalf("expected the process to be forced to exit with code 1, got: %d", exitCode)
+			}
+		})
+	}
+}
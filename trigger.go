@@ -0,0 +1,27 @@
+package rununtil
+
+import "sync"
+
+// ShutdownTrigger returns a function which, when called from anywhere, e.g. an
+// HTTP handler, initiates the same graceful shutdown of the awaits on the
+// Group as a kill signal would. The returned function only triggers the
+// shutdown the first time it is called, so it is safe to call more than once,
+// e.g. when an admin endpoint is hit repeatedly. For example:
+//
+//	trigger := group.ShutdownTrigger()
+//	r.Post("/shutdown", func(w http.ResponseWriter, r *http.Request) {
+//		trigger()
+//		w.WriteHeader(http.StatusAccepted)
+//	})
+func (g *Group) ShutdownTrigger() func() {
+	var once sync.Once
+	return func() {
+		once.Do(g.Stop)
+	}
+}
+
+// ShutdownTrigger returns a function which initiates the graceful shutdown of
+// the awaits on the default Group, see Group.ShutdownTrigger.
+func ShutdownTrigger() func() {
+	return defaultGroup.ShutdownTrigger()
+}
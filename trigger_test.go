@@ -0,0 +1,39 @@
+package rununtil_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"syscall"
+	"testing"
+
+	"github.com/mec07/rununtil"
+)
+
+func TestRununtilShutdownTrigger(t *testing.T) {
+	trigger := rununtil.ShutdownTrigger()
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		trigger()
+		w.WriteHeader(http.StatusAccepted)
+	})
+
+	var hasBeenShutdown bool
+	triggeringRunner := rununtil.RunnerFunc(func() rununtil.ShutdownFunc {
+		for idx := 0; idx < 2; idx++ {
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/shutdown", nil))
+			if rec.Code != http.StatusAccepted {
+				t.Errorf("expected status %d, got: %d", http.StatusAccepted, rec.Code)
+			}
+		}
+		return nil
+	})
+
+	rununtil.Await(
+		[]rununtil.RunnerFunc{helperMakeFakeRunner(&hasBeenShutdown), triggeringRunner},
+		rununtil.WithSignals(syscall.SIGINT),
+	)
+
+	if !hasBeenShutdown {
+		t.Fatal("expected the shutdown function to have been called")
+	}
+}
@@ -0,0 +1,33 @@
+package rununtil
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+// AwaitKillSignalsWithWatchdog runs the provided RunnerFuncs until the
+// specified signals have been recieved, at which point it starts a watchdog
+// timer and executes the graceful shutdown functions in the reverse order to
+// which the runners were registered. If the shutdown functions have not all
+// returned by the time gracePeriod has elapsed, the watchdog reports that it
+// fired on stderr and forces the process to exit with status code 1.
+// Unlike AwaitKillSignalsWithTimeout, the shutdown functions are run
+// sequentially and the watchdog guarantees that the process dies even if a
+// shutdown function deadlocks in code which cannot be cancelled.
+func AwaitKillSignalsWithWatchdog(signals []os.Signal, gracePeriod time.Duration, runnerFuncs ...RunnerFunc) {
+	wait, release := defaultGroup.listenForKillSignal(signals)
+	defer release()
+
+	shutdowns := startRunners(runnerFuncs)
+
+	wait()
+
+	watchdog := time.AfterFunc(gracePeriod, func() {
+		fmt.Fprintf(os.Stderr, "ERROR: watchdog fired: graceful shutdown did not complete within %v, forcing exit\n", gracePeriod)
+		osExit(1)
+	})
+	defer watchdog.Stop()
+
+	shutdownInReverse(shutdowns)
+}
@@ -0,0 +1,62 @@
+package rununtil_test
+
+import (
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/mec07/rununtil"
+)
+
+func TestRununtilAwaitKillSignalsWithWatchdog(t *testing.T) {
+	var exitCalled bool
+	restore := rununtil.SetOsExit(func(code int) { exitCalled = true })
+	defer restore()
+
+	var hasBeenShutdown bool
+	rununtil.AwaitKillSignalsWithWatchdog(
+		[]os.Signal{syscall.SIGINT},
+		time.Second,
+		helperMakeFakeRunner(&hasBeenShutdown),
+		helperMakeCancellingRunner(),
+	)
+
+	if !hasBeenShutdown {
+		t.Fatal("expected the shutdown function to have been called")
+	}
+	// make sure that the watchdog has been stopped
+	time.Sleep(10 * time.Millisecond)
+	if exitCalled {
+		t.Fatal("did not expect the watchdog to have fired")
+	}
+}
+
+func TestRununtilAwaitKillSignalsWithWatchdog_Fires(t *testing.T) {
+	// the stubbed exit cannot actually kill the deadlocked shutdown function,
+	// so it releases it instead
+	deadlocked := make(chan struct{})
+	exitCode := -1
+	restore := rununtil.SetOsExit(func(code int) {
+		exitCode = code
+		close(deadlocked)
+	})
+	defer restore()
+
+	deadlockedRunner := rununtil.RunnerFunc(func() rununtil.ShutdownFunc {
+		return rununtil.ShutdownFunc(func() {
+			<-deadlocked
+		})
+	})
+
+	rununtil.AwaitKillSignalsWithWatchdog(
+		[]os.Signal{syscall.SIGINT},
+		10*time.Millisecond,
+		deadlockedRunner,
+		helperMakeCancellingRunner(),
+	)
+
+	if exitCode != 1 {
+		t.Fatalf("expected the watchdog to force an exit with code 1, got: %d", exitCode)
+	}
+}
@@ -0,0 +1,152 @@
+package rununtil
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// FromWorkers combines the workers into a single RunnerFunc, which runs each
+// of the workers in its own go routine with a shared context. Its ShutdownFunc
+// cancels the context and waits for all of the workers to return.
+// An error returned by a worker does not stop the others; the first one is
+// retained and can be retrieved with the returned function once the workers
+// have been shutdown. A worker returning context.Canceled after the context
+// has been cancelled is not treated as an error.
+func FromWorkers(workers ...func(ctx context.Context) error) (RunnerFunc, func() error) {
+	var mux sync.Mutex
+	var firstErr error
+	recordErr := func(err error) {
+		mux.Lock()
+		defer mux.Unlock()
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	runner := RunnerFunc(func() ShutdownFunc {
+		ctx, cancel := context.WithCancel(context.Background())
+
+		var wg sync.WaitGroup
+		for _, worker := range workers {
+			wg.Add(1)
+			go func(worker func(ctx context.Context) error) {
+				defer wg.Done()
+				err := worker(ctx)
+				if err == nil || (ctx.Err() != nil && errors.Cause(err) == context.Canceled) {
+					return
+				}
+				recordErr(err)
+			}(worker)
+		}
+
+		return ShutdownFunc(func() {
+			cancel()
+			wg.Wait()
+		})
+	})
+
+	return runner, func() error {
+		mux.Lock()
+		defer mux.Unlock()
+		return firstErr
+	}
+}
+
+// WorkerPool returns a RunnerFunc which starts n go routines, each running
+// work with a shared context, e.g. a fixed pool of workers draining a queue.
+// Its ShutdownFunc cancels the context and waits for all of the go routines to
+// return, so work must return promptly once the context has been cancelled.
+// The wait is bounded by the default Group's ShutdownContext, i.e. by the
+// deadline of the await's shutdown or else by DefaultShutdownTimeout: any
+// workers which have not returned by then are reported on stderr and
+// abandoned. WorkerPool panics if n is negative.
+func WorkerPool(n int, work func(ctx context.Context)) RunnerFunc {
+	return defaultGroup.WorkerPool(n, work)
+}
+
+// WorkerPool is the same as the package level WorkerPool except that the wait
+// for the workers is bounded by g's ShutdownContext.
+func (g *Group) WorkerPool(n int, work func(ctx context.Context)) RunnerFunc {
+	if n < 0 {
+		panic(fmt.Sprintf("rununtil: WorkerPool called with a negative number of workers: %d", n))
+	}
+	return func() ShutdownFunc {
+		ctx, cancel := context.WithCancel(context.Background())
+
+		var wg sync.WaitGroup
+		wg.Add(n)
+		for idx := 0; idx < n; idx++ {
+			go func() {
+				defer wg.Done()
+				work(ctx)
+			}()
+		}
+
+		return ShutdownFunc(func() {
+			cancel()
+			g.waitWithinShutdown(&wg, "WorkerPool workers")
+		})
+	}
+}
+
+// waitWithinShutdown waits for wg, giving up once g's ShutdownContext is done,
+// in which case the abandoned go routines, described by what, are reported on
+// stderr.
+func (g *Group) waitWithinShutdown(wg *sync.WaitGroup, what string) {
+	ctx, cancel := g.ShutdownContext()
+	defer cancel()
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		fmt.Fprintf(os.Stderr, "ERROR: %+v\n", errors.Wrapf(ctx.Err(), "waiting for the %s to return, abandoning them", what))
+	}
+}
+
+// Managed returns a RunnerFunc which calls start with a context and a
+// WaitGroup. The go routines that start launches must call wg.Add before they
+// are launched and wg.Done once they have returned, and must return once the
+// context has been cancelled. The ShutdownFunc cancels the context and then
+// waits for the WaitGroup, so that the go routines can't be forgotten about:
+//
+//	rununtil.AwaitKillSignal(rununtil.Managed(func(ctx context.Context, wg *sync.WaitGroup) {
+//		wg.Add(1)
+//		go func() {
+//			defer wg.Done()
+//			consume(ctx, queue)
+//		}()
+//	}))
+//
+// The go routines are given until the deadline of the await's shutdown, or
+// else DefaultShutdownTimeout, to call wg.Done; if the WaitGroup has not
+// reached zero by then, Managed stops waiting for it and reports that on
+// stderr, leaving the stragglers running.
+func Managed(start func(ctx context.Context, wg *sync.WaitGroup)) RunnerFunc {
+	return defaultGroup.Managed(start)
+}
+
+// Managed is the same as the package level Managed except that the wait for
+// the WaitGroup is bounded by g's ShutdownContext.
+func (g *Group) Managed(start func(ctx context.Context, wg *sync.WaitGroup)) RunnerFunc {
+	return func() ShutdownFunc {
+		ctx, cancel := context.WithCancel(context.Background())
+
+		var wg sync.WaitGroup
+		start(ctx, &wg)
+
+		return ShutdownFunc(func() {
+			cancel()
+			g.waitWithinShutdown(&wg, "Managed go routines")
+		})
+	}
+}
@@ -0,0 +1,254 @@
+package rununtil_test
+
+import (
+	"context"
+	"os"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/mec07/rununtil"
+	"github.com/pkg/errors"
+)
+
+func TestFromWorkers(t *testing.T) {
+	errWorker := errors.New("worker failed")
+	failed := make(chan struct{})
+	var stopped int
+
+	runner, workersErr := rununtil.FromWorkers(
+		func(ctx context.Context) error {
+			defer close(failed)
+			return errWorker
+		},
+		func(ctx context.Context) error {
+			<-ctx.Done()
+			stopped++
+			return ctx.Err()
+		},
+	)
+	cancelOnceFailed := rununtil.RunnerFunc(func() rununtil.ShutdownFunc {
+		go func() {
+			<-failed
+			rununtil.CancelAll()
+		}()
+		return rununtil.ShutdownFunc(func() {})
+	})
+
+	rununtil.AwaitKillSignals([]os.Signal{syscall.SIGINT}, runner, cancelOnceFailed)
+
+	if stopped != 1 {
+		t.Fatal("expected the long running worker to have been stopped")
+	}
+	if err := workersErr(); err != errWorker {
+		t.Fatalf("expected the worker error to be retained, got: %v", err)
+	}
+}
+
+func TestFromWorkers_NoErrors(t *testing.T) {
+	runner, workersErr := rununtil.FromWorkers(
+		func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		},
+	)
+
+	rununtil.AwaitKillSignals([]os.Signal{syscall.SIGINT}, runner, helperMakeCancellingRunner())
+
+	if err := workersErr(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestWorkerPool(t *testing.T) {
+	var started, stopped int32
+	allStarted := make(chan struct{})
+	pool := rununtil.WorkerPool(3, func(ctx context.Context) {
+		if atomic.AddInt32(&started, 1) == 3 {
+			close(allStarted)
+		}
+		<-ctx.Done()
+		atomic.AddInt32(&stopped, 1)
+	})
+	cancelOnceStarted := rununtil.RunnerFunc(func() rununtil.ShutdownFunc {
+		go func() {
+			<-allStarted
+			rununtil.CancelAll()
+		}()
+		return rununtil.ShutdownFunc(func() {})
+	})
+
+	rununtil.AwaitKillSignals([]os.Signal{syscall.SIGINT}, pool, cancelOnceStarted)
+
+	if stopped := atomic.LoadInt32(&stopped); stopped != 3 {
+		t.Fatalf("expected all 3 workers to have returned before the shutdown completed, got: %d", stopped)
+	}
+}
+
+func TestManaged(t *testing.T) {
+	var stopped int32
+	managed := rununtil.Managed(func(ctx context.Context, wg *sync.WaitGroup) {
+		for idx := 0; idx < 2; idx++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				<-ctx.Done()
+				time.Sleep(time.Millisecond)
+				atomic.AddInt32(&stopped, 1)
+			}()
+		}
+	})
+
+	rununtil.AwaitKillSignals([]os.Signal{syscall.SIGINT}, managed, helperMakeCancellingRunner())
+
+	if stopped := atomic.LoadInt32(&stopped); stopped != 2 {
+		t.Fatalf("expected both go routines to have returned before the shutdown completed, got: %d", stopped)
+	}
+}
+
+func TestWorkerPool_NegativeCountPanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected WorkerPool to panic for a negative number of workers")
+		}
+	}()
+	rununtil.WorkerPool(-1, func(ctx context.Context) {})
+}
+
+func TestWorkerPool_WaitBoundedByDefaultShutdownTimeout(t *testing.T) {
+	defer func(timeout time.Duration) { rununtil.DefaultShutdownTimeout = timeout }(rununtil.DefaultShutdownTimeout)
+	rununtil.DefaultShutdownTimeout = 10 * time.Millisecond
+
+	release := make(chan struct{})
+	defer close(release)
+	shutdown := rununtil.WorkerPool(1, func(ctx context.Context) {
+		// ignores the cancellation of the context
+		<-release
+	})()
+
+	done := make(chan struct{})
+	go func() {
+		shutdown()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("expected the wait for the workers to be abandoned once DefaultShutdownTimeout elapsed")
+	}
+}
+
+func TestGroupWorkerPool_WaitBoundedByGroupTimeout(t *testing.T) {
+	defer func(timeout time.Duration) { rununtil.DefaultShutdownTimeout = timeout }(rununtil.DefaultShutdownTimeout)
+	rununtil.DefaultShutdownTimeout = time.Minute
+	restore := rununtil.SetOsExit(func(code int) {})
+	defer restore()
+
+	release := make(chan struct{})
+	defer close(release)
+	group := rununtil.NewGroup(rununtil.WithOptions(rununtil.WithTimeout(10 * time.Millisecond)))
+	pool := group.WorkerPool(1, func(ctx context.Context) {
+		// ignores the cancellation of the context
+		<-release
+	})
+	shutdownDone := make(chan struct{})
+	runner := rununtil.RunnerFunc(func() rununtil.ShutdownFunc {
+		shutdown := pool()
+		return func() {
+			shutdown()
+			close(shutdownDone)
+		}
+	})
+
+	awaitDone := make(chan struct{})
+	go func() {
+		defer close(awaitDone)
+		group.AwaitResult([]rununtil.RunnerFunc{runner})
+	}()
+	for group.ActiveCount() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	group.Stop()
+
+	select {
+	case <-shutdownDone:
+	case <-time.After(time.Second):
+		t.Fatal("expected the wait for the workers to be abandoned at the deadline of the group's shutdown")
+	}
+	// the await must have returned before osExit is restored
+	<-awaitDone
+}
+
+func TestManaged_WaitBoundedByDefaultShutdownTimeout(t *testing.T) {
+	defer func(timeout time.Duration) { rununtil.DefaultShutdownTimeout = timeout }(rununtil.DefaultShutdownTimeout)
+	rununtil.DefaultShutdownTimeout = 10 * time.Millisecond
+
+	release := make(chan struct{})
+	defer close(release)
+	shutdown := rununtil.Managed(func(ctx context.Context, wg *sync.WaitGroup) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// ignores the cancellation of the context
+			<-release
+		}()
+	})()
+
+	done := make(chan struct{})
+	go func() {
+		shutdown()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("expected the wait for the WaitGroup to be abandoned once DefaultShutdownTimeout elapsed")
+	}
+}
+
+func TestGroupManaged_WaitBoundedByGroupTimeout(t *testing.T) {
+	defer func(timeout time.Duration) { rununtil.DefaultShutdownTimeout = timeout }(rununtil.DefaultShutdownTimeout)
+	rununtil.DefaultShutdownTimeout = time.Minute
+	restore := rununtil.SetOsExit(func(code int) {})
+	defer restore()
+
+	release := make(chan struct{})
+	defer close(release)
+	group := rununtil.NewGroup(rununtil.WithOptions(rununtil.WithTimeout(10 * time.Millisecond)))
+	managed := group.Managed(func(ctx context.Context, wg *sync.WaitGroup) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// ignores the cancellation of the context
+			<-release
+		}()
+	})
+	shutdownDone := make(chan struct{})
+	runner := rununtil.RunnerFunc(func() rununtil.ShutdownFunc {
+		shutdown := managed()
+		return func() {
+			shutdown()
+			close(shutdownDone)
+		}
+	})
+
+	awaitDone := make(chan struct{})
+	go func() {
+		defer close(awaitDone)
+		group.AwaitResult([]rununtil.RunnerFunc{runner})
+	}()
+	for group.ActiveCount() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	group.Stop()
+
+	select {
+	case <-shutdownDone:
+	case <-time.After(time.Second):
+		t.Fatal("expected the wait for the WaitGroup to be abandoned at the deadline of the group's shutdown")
+	}
+	// the await must have returned before osExit is restored
+	<-awaitDone
+}